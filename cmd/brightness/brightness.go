@@ -0,0 +1,66 @@
+/*
+Copyright © 2024 Neil Johnson <nj.designs@protonmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package brightness
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/nj-designs/go-idot/cmd/devopts"
+	"github.com/nj-designs/go-idot/idot"
+	"github.com/spf13/cobra"
+)
+
+var percent int
+var devOpts devopts.Options
+
+var Cmd = &cobra.Command{
+	Use:   "brightness",
+	Short: "Sets the brightness of the iDot display",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := doSetBrightness(cmd.Context()); err != nil {
+			fmt.Printf("error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	devOpts.AddFlags(Cmd)
+	Cmd.Flags().IntVar(&percent, "percent", 0, fmt.Sprintf("Brightness in percent (%d-%d)", idot.MinBrightness, idot.MaxBrightness))
+	Cmd.MarkFlagRequired("percent")
+}
+
+func doSetBrightness(ctx context.Context) error {
+	if percent < idot.MinBrightness || percent > idot.MaxBrightness {
+		return idot.ErrInvalidBrightness
+	}
+
+	device, err := devOpts.Open(ctx)
+	if err != nil {
+		return err
+	}
+	defer device.Disconnect()
+
+	return device.SetBrightness(percent)
+}
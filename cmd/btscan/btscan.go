@@ -22,25 +22,30 @@ THE SOFTWARE.
 package btscan
 
 import (
+	"context"
 	"fmt"
-	"math"
-	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"github.com/nj-designs/go-idot/idot"
 	"github.com/spf13/cobra"
-	"tinygo.org/x/bluetooth"
 )
 
 var maxScanTime uint32
 var verbose bool
+var displaysOnly bool
+var adapterID string
 
 var Cmd = &cobra.Command{
 	Use:   "btscan",
 	Short: "Displays a list of bluetooth devices that can be seen by the local adapter",
 	Run: func(cmd *cobra.Command, args []string) {
-		if err := doBTScan(); err != nil {
+		doScan := doBTScan
+		if displaysOnly {
+			doScan = doDiscover
+		}
+		if err := doScan(); err != nil {
 			fmt.Printf("Failed: %v\n", err)
 		}
 	},
@@ -49,48 +54,53 @@ var Cmd = &cobra.Command{
 func init() {
 	Cmd.Flags().Uint32Var(&maxScanTime, "scan-time", 0, "Max number of seconds to perform scan. 0 means infinite")
 	Cmd.Flags().BoolVar(&verbose, "verbose", false, "Verbose output during scan")
+	Cmd.Flags().StringVar(&adapterID, "adapter", "", "Bluetooth adapter to use, e.g. hci1. Defaults to the system default adapter")
+	Cmd.Flags().BoolVar(&displaysOnly, "displays", false, "Only list iDotMatrix displays, strongest signal first. 0 scan-time means 5 seconds")
 }
 
-func doBTScan() error {
+func doDiscover() error {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 
-	if maxScanTime == 0 {
-		maxScanTime = math.MaxUint32
+	scanTime := time.Second * time.Duration(maxScanTime)
+	if scanTime == 0 {
+		scanTime = idot.DefaultDiscoveryTime
 	}
+	fmt.Printf("Scanning for %s\n", scanTime)
 
-	sigs := make(chan os.Signal, 1)
-	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
-
-	adapter := bluetooth.DefaultAdapter
-
-	if err := adapter.Enable(); err != nil {
+	displays, err := idot.Discover(ctx, idot.DeviceOptions{ScanTimeout: scanTime, AdapterID: adapterID})
+	if err != nil {
 		return err
 	}
 
-	scanTimer := time.NewTimer(time.Second * time.Duration(maxScanTime))
-	go func() {
-		select {
-		case <-sigs:
-			scanTimer.Stop()
-		case <-scanTimer.C:
-		}
-		adapter.StopScan()
-	}()
+	fmt.Println("Scan results")
+	for _, disp := range displays {
+		fmt.Printf("Address:%s  RSSI:%3d  Name:%s\n", disp.Address, disp.RSSI, disp.Name)
+	}
+
+	return nil
+}
 
-	scanResults := make(map[string]bluetooth.ScanResult)
+func doBTScan() error {
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 
-	if maxScanTime == math.MaxUint32 {
+	if maxScanTime == 0 {
 		fmt.Println("Scanning forever [CTRL+C to stop]")
 	} else {
 		fmt.Printf("Scanning for %d second(s)\n", maxScanTime)
 	}
 
-	err := adapter.Scan(func(adapter *bluetooth.Adapter, result bluetooth.ScanResult) {
-		addr := result.Address.String()
-		if _, prs := scanResults[addr]; !prs {
+	scanResults := make(map[string]idot.Display)
+
+	opts := idot.DeviceOptions{ScanTimeout: time.Second * time.Duration(maxScanTime), AdapterID: adapterID}
+	err := idot.Scan(ctx, opts, func(result idot.Display) {
+		if _, prs := scanResults[result.Address]; !prs {
 			if verbose {
-				fmt.Printf("Found Device at %s\n", addr)
+				fmt.Printf("Found Device at %s\n", result.Address)
 			}
-			scanResults[addr] = result
+			scanResults[result.Address] = result
 		}
 	})
 	if err != nil {
@@ -99,7 +109,7 @@ func doBTScan() error {
 
 	fmt.Println("Scan results")
 	for _, sr := range scanResults {
-		fmt.Printf("Address:%s  RSSI:%3d  Name:%s\n", sr.Address.String(), sr.RSSI, sr.LocalName())
+		fmt.Printf("Address:%s  RSSI:%3d  Name:%s\n", sr.Address, sr.RSSI, sr.Name)
 	}
 
 	return nil
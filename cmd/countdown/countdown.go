@@ -0,0 +1,83 @@
+/*
+Copyright © 2024 Neil Johnson <nj.designs@protonmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package countdown
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/nj-designs/go-idot/cmd/devopts"
+	"github.com/nj-designs/go-idot/idot"
+	"github.com/spf13/cobra"
+)
+
+var duration time.Duration
+var devOpts devopts.Options
+
+var Cmd = &cobra.Command{
+	Use:       "countdown start|pause|restart|stop",
+	Short:     "Controls the iDot countdown timer",
+	Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	ValidArgs: []string{"start", "pause", "restart", "stop"},
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := doCountdown(cmd.Context(), args[0]); err != nil {
+			fmt.Printf("error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	devOpts.AddFlags(Cmd)
+	Cmd.Flags().DurationVar(&duration, "duration", 5*time.Minute, "Time to count down from, e.g. 90s or 15m (max 99m59s)")
+}
+
+// Split returns d as whole minutes and seconds, as taken by Device.Countdown
+func Split(d time.Duration) (int, int, error) {
+	d = d.Round(time.Second)
+	minutes, seconds := int(d/time.Minute), int(d%time.Minute/time.Second)
+	if d < 0 || minutes > idot.MaxCountdownMinutes {
+		return 0, 0, idot.ErrInvalidCountdown
+	}
+	return minutes, seconds, nil
+}
+
+func doCountdown(ctx context.Context, name string) error {
+	action, err := idot.ParseCountdownAction(name)
+	if err != nil {
+		return err
+	}
+	minutes, seconds, err := Split(duration)
+	if err != nil {
+		return err
+	}
+
+	device, err := devOpts.Open(ctx)
+	if err != nil {
+		return err
+	}
+	defer device.Disconnect()
+
+	return device.Countdown(action, minutes, seconds)
+}
@@ -0,0 +1,128 @@
+/*
+Copyright © 2024 Neil Johnson <nj.designs@protonmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package decode
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/nj-designs/go-idot/idot"
+	"github.com/spf13/cobra"
+)
+
+var inputFile string
+var notifications bool
+
+var Cmd = &cobra.Command{
+	Use:   "decode [hex-packet...]",
+	Short: "Prints a breakdown of iDot packets given as hex or in a capture file",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := doDecode(args); err != nil {
+			fmt.Printf("error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	Cmd.Flags().StringVar(&inputFile, "file", "", "Capture file, or file with one hex packet per line, to decode. - reads stdin")
+	Cmd.Flags().BoolVar(&notifications, "rx", false, "Decode hex packets as notifications from the display rather than packets sent to it")
+}
+
+// ParseHex decodes a packet written as hex. Spaces, colons and dashes
+// between bytes are ignored, as is a 0x prefix.
+func ParseHex(s string) ([]byte, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "0x")
+	s = strings.NewReplacer(" ", "", ":", "", "-", "").Replace(s)
+	return hex.DecodeString(s)
+}
+
+func doDecode(args []string) error {
+	if len(args) == 0 && len(inputFile) == 0 {
+		return fmt.Errorf("give hex packets or --file")
+	}
+
+	dir := idot.CaptureTx
+	if notifications {
+		dir = idot.CaptureRx
+	}
+	for _, arg := range args {
+		packet, err := ParseHex(arg)
+		if err != nil {
+			return err
+		}
+		printRecord(idot.CaptureRecord{Dir: dir, Data: packet})
+	}
+
+	if len(inputFile) == 0 {
+		return nil
+	}
+	var r io.Reader = os.Stdin
+	if inputFile != "-" {
+		f, err := os.Open(inputFile)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if len(text) == 0 {
+			continue
+		}
+		rec := idot.CaptureRecord{Dir: dir}
+		var err error
+		if strings.HasPrefix(text, "{") {
+			err = json.Unmarshal([]byte(text), &rec)
+		} else {
+			rec.Data, err = ParseHex(text)
+		}
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		printRecord(rec)
+	}
+	return scanner.Err()
+}
+
+func printRecord(rec idot.CaptureRecord) {
+	var p idot.DecodedPacket
+	if rec.Dir == idot.CaptureRx {
+		p = idot.DecodeNotification(rec.Data)
+	} else {
+		p = idot.DecodePacket(rec.Data)
+	}
+	prefix := rec.Dir
+	if !rec.Time.IsZero() {
+		prefix += " " + rec.Time.Local().Format("15:04:05.000")
+	}
+	fmt.Printf("%s %s", prefix, p)
+}
@@ -0,0 +1,137 @@
+/*
+Copyright © 2024 Neil Johnson <nj.designs@protonmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+/*
+Package devopts holds the command line options shared by the sub commands that talk to a display
+*/
+package devopts
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/nj-designs/go-idot/idot"
+	"github.com/spf13/cobra"
+)
+
+type Options struct {
+	Target      string
+	AdapterID   string
+	PanelSize   idot.PanelSize
+	ScanTimeout time.Duration
+	WriteSize   int
+	WriteDelay  time.Duration
+	Capture     string
+
+	// Supervise and ReconnectWait aren't flags. Long running commands set
+	// them to keep the connection alive.
+	Supervise     bool
+	ReconnectWait time.Duration
+}
+
+// AddFlags registers the options as flags of cmd
+func (o *Options) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&o.Target, "target", "", "Target iDot display MAC address, name (wildcards allowed, e.g. 'IDM-*') or 'auto' for the strongest signal")
+	cmd.Flags().StringVar(&o.AdapterID, "adapter", "", "Bluetooth adapter to use, e.g. hci1. Defaults to the system default adapter")
+	cmd.Flags().Var(&panelSizeValue{&o.PanelSize}, "panel-size", "Size of the display. 16, 32 or 64 (default 32)")
+	cmd.Flags().DurationVar(&o.ScanTimeout, "scan-timeout", idot.DefaultScanTimeout, "Max time to scan for the target display")
+	cmd.Flags().IntVar(&o.WriteSize, "write-size", 0, "Max bytes per Bluetooth write. 0 means use the negotiated MTU")
+	cmd.Flags().DurationVar(&o.WriteDelay, "write-delay", 0, "Minimum delay between Bluetooth writes, e.g. 10ms")
+	cmd.Flags().StringVar(&o.Capture, "capture", "", "Append every packet sent to and received from the display to this file, as JSON lines")
+}
+
+// Size returns the size of the display, as given by --panel-size
+func (o *Options) Size() idot.PanelSize {
+	if o.PanelSize == 0 {
+		return idot.DefaultPanelSize
+	}
+	return o.PanelSize
+}
+
+// NewDevice finds the target display
+func (o *Options) NewDevice(ctx context.Context) (*idot.Device, error) {
+	if len(o.Target) == 0 {
+		return nil, fmt.Errorf("missing --target option")
+	}
+	return idot.NewDeviceContext(ctx, o.Target, idot.DeviceOptions{
+		ScanTimeout: o.ScanTimeout,
+		AdapterID:   o.AdapterID,
+		PanelSize:   o.PanelSize,
+	})
+}
+
+// Connect connects device using the options
+func (o *Options) Connect(device *idot.Device) error {
+	if len(o.Capture) > 0 {
+		// Left open until exit. Records are written unbuffered so none are lost.
+		f, err := os.OpenFile(o.Capture, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+		if err != nil {
+			return err
+		}
+		device.SetCapture(idot.NewCaptureWriter(f))
+	}
+	return device.ConnectWithOptions(idot.ConnectOptions{
+		WriteSize:     o.WriteSize,
+		WriteDelay:    o.WriteDelay,
+		Supervise:     o.Supervise,
+		ReconnectWait: o.ReconnectWait,
+	})
+}
+
+// Open finds and connects to the target display
+func (o *Options) Open(ctx context.Context) (*idot.Device, error) {
+	device, err := o.NewDevice(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := o.Connect(device); err != nil {
+		return nil, err
+	}
+	return device, nil
+}
+
+// panelSizeValue adapts idot.PanelSize to a pflag.Value
+type panelSizeValue struct {
+	size *idot.PanelSize
+}
+
+func (v *panelSizeValue) String() string {
+	if *v.size == 0 {
+		return ""
+	}
+	return v.size.String()
+}
+
+func (v *panelSizeValue) Set(s string) error {
+	size, err := idot.ParsePanelSize(s)
+	if err != nil {
+		return err
+	}
+	*v.size = size
+	return nil
+}
+
+func (v *panelSizeValue) Type() string {
+	return "size"
+}
@@ -0,0 +1,88 @@
+/*
+Copyright © 2024 Neil Johnson <nj.designs@protonmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package effect
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/nj-designs/go-idot/cmd/devopts"
+	"github.com/nj-designs/go-idot/idot"
+	"github.com/spf13/cobra"
+)
+
+var style string
+var colours []string
+var devOpts devopts.Options
+
+var Cmd = &cobra.Command{
+	Use:   "effect",
+	Short: "Shows one of the iDot display's built in animations",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := doEffect(cmd.Context()); err != nil {
+			fmt.Printf("error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	devOpts.AddFlags(Cmd)
+	Cmd.Flags().StringVar(&style, "style", idot.EffectRainbowHorizontal.String(), fmt.Sprintf("Effect to show. One of %s", strings.Join(idot.EffectStyleNames(), ", ")))
+	Cmd.Flags().StringArrayVar(&colours, "colour", nil, fmt.Sprintf("RGB colour used by the effect. Format: R,G,B (0-255). Repeat %d to %d times", idot.MinEffectColours, idot.MaxEffectColours))
+	Cmd.MarkFlagRequired("colour")
+}
+
+// Options parses the effect style name and colours
+func Options(style string, colours []string) (idot.EffectStyle, []idot.Colour, error) {
+	s, err := idot.ParseEffectStyle(style)
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(colours) < idot.MinEffectColours || len(colours) > idot.MaxEffectColours {
+		return 0, nil, fmt.Errorf("effect needs between %d and %d colours, got %d", idot.MinEffectColours, idot.MaxEffectColours, len(colours))
+	}
+	cs := make([]idot.Colour, len(colours))
+	for i, c := range colours {
+		if cs[i], err = idot.ColourFromString(c); err != nil {
+			return 0, nil, err
+		}
+	}
+	return s, cs, nil
+}
+
+func doEffect(ctx context.Context) error {
+	s, cs, err := Options(style, colours)
+	if err != nil {
+		return err
+	}
+
+	device, err := devOpts.Open(ctx)
+	if err != nil {
+		return err
+	}
+	defer device.Disconnect()
+
+	return device.SetEffect(s, cs)
+}
@@ -0,0 +1,80 @@
+/*
+Copyright © 2024 Neil Johnson <nj.designs@protonmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package fill
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/nj-designs/go-idot/cmd/devopts"
+	"github.com/nj-designs/go-idot/cmd/preview"
+	"github.com/nj-designs/go-idot/idot"
+	"github.com/spf13/cobra"
+)
+
+var colour string
+var devOpts devopts.Options
+var previewOpts preview.Options
+
+var Cmd = &cobra.Command{
+	Use:   "fill",
+	Short: "Fills the iDot display with a single colour",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := doFill(cmd.Context()); err != nil {
+			fmt.Printf("error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	devOpts.AddFlags(Cmd)
+	previewOpts.AddFlags(Cmd)
+	Cmd.Flags().StringVar(&colour, "colour", "", "RGB colour to fill with. Format: R,G,B (0-255)")
+	Cmd.MarkFlagRequired("colour")
+}
+
+func doFill(ctx context.Context) error {
+	c, err := idot.ColourFromString(colour)
+	if err != nil {
+		return err
+	}
+	if previewOpts.Preview {
+		fb := idot.NewFramebuffer(devOpts.Size())
+		fb.Clear(c)
+		if err := previewOpts.Show(fb); err != nil {
+			return err
+		}
+	}
+	if previewOpts.DryRun {
+		return nil
+	}
+
+	device, err := devOpts.Open(ctx)
+	if err != nil {
+		return err
+	}
+	defer device.Disconnect()
+
+	return device.FillColour(c)
+}
@@ -0,0 +1,121 @@
+/*
+Copyright © 2024 Neil Johnson <nj.designs@protonmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+/*
+Package preview renders what a sub command would send to the display in the terminal
+*/
+package preview
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+type Options struct {
+	Preview bool
+	DryRun  bool
+}
+
+// AddFlags registers the options as flags of cmd
+func (o *Options) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&o.Preview, "preview", false, "Show what will be sent to the display in the terminal. Needs a truecolor terminal")
+	cmd.Flags().BoolVar(&o.DryRun, "dry-run", false, "Don't connect to the display, just prepare and optionally --preview the result")
+}
+
+// Show renders img to stdout when --preview is set
+func (o *Options) Show(img image.Image) error {
+	if !o.Preview {
+		return nil
+	}
+	return Render(os.Stdout, img)
+}
+
+// ShowGIF plays g once on stdout when --preview is set
+func (o *Options) ShowGIF(g *gif.GIF) error {
+	if !o.Preview {
+		return nil
+	}
+	return RenderGIF(os.Stdout, g)
+}
+
+// Render draws img using truecolor ANSI escapes. Each character is an upper
+// half block showing two pixels, one above the other.
+func Render(w io.Writer, img image.Image) error {
+	bw := bufio.NewWriter(w)
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y += 2 {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			top := color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)
+			fmt.Fprintf(bw, "\x1b[38;2;%d;%d;%dm", top.R, top.G, top.B)
+			if y+1 < b.Max.Y {
+				bottom := color.RGBAModel.Convert(img.At(x, y+1)).(color.RGBA)
+				fmt.Fprintf(bw, "\x1b[48;2;%d;%d;%dm", bottom.R, bottom.G, bottom.B)
+			}
+			bw.WriteString("▀")
+		}
+		bw.WriteString("\x1b[0m\n")
+	}
+	return bw.Flush()
+}
+
+// RenderGIF plays each frame of g in turn, drawing over the previous one
+func RenderGIF(w io.Writer, g *gif.GIF) error {
+	canvas := image.NewRGBA(image.Rect(0, 0, g.Config.Width, g.Config.Height))
+	rows := (canvas.Bounds().Dy() + 1) / 2
+	for i, frame := range g.Image {
+		var restore *image.RGBA
+		if i < len(g.Disposal) && g.Disposal[i] == gif.DisposalPrevious {
+			restore = image.NewRGBA(canvas.Bounds())
+			draw.Draw(restore, canvas.Bounds(), canvas, image.Point{}, draw.Src)
+		}
+		draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+
+		if i > 0 {
+			// Move back up to redraw in place
+			fmt.Fprintf(w, "\x1b[%dA", rows)
+		}
+		if err := Render(w, canvas); err != nil {
+			return err
+		}
+		if i < len(g.Delay) {
+			time.Sleep(time.Duration(g.Delay[i]) * 10 * time.Millisecond)
+		}
+
+		if i < len(g.Disposal) {
+			switch g.Disposal[i] {
+			case gif.DisposalBackground:
+				draw.Draw(canvas, frame.Bounds(), image.Transparent, image.Point{}, draw.Src)
+			case gif.DisposalPrevious:
+				canvas = restore
+			}
+		}
+	}
+	return nil
+}
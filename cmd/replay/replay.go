@@ -0,0 +1,140 @@
+/*
+Copyright © 2024 Neil Johnson <nj.designs@protonmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package replay
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/nj-designs/go-idot/cmd/devopts"
+	"github.com/nj-designs/go-idot/idot"
+	"github.com/spf13/cobra"
+)
+
+var keepTiming bool
+var replyTimeout time.Duration
+var devOpts devopts.Options
+
+var Cmd = &cobra.Command{
+	Use:   "replay <capture-file>",
+	Short: "Re-sends the packets in a capture file to the iDot display",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := doReplay(cmd.Context(), args[0]); err != nil {
+			fmt.Printf("error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	devOpts.AddFlags(Cmd)
+	Cmd.Flags().BoolVar(&keepTiming, "keep-timing", false, "Keep the original gaps between packets")
+	Cmd.Flags().DurationVar(&replyTimeout, "reply-timeout", idot.DefaultUploadOptions.AckTimeout, "How long to wait for the display to reply to packets it replied to in the capture")
+}
+
+// expectsReply reports whether the display replied to records[i] before
+// the next packet was sent
+func expectsReply(records []idot.CaptureRecord, i int) bool {
+	for _, rec := range records[i+1:] {
+		switch rec.Dir {
+		case idot.CaptureRx:
+			return true
+		case idot.CaptureTx:
+			return false
+		}
+	}
+	return false
+}
+
+func doReplay(ctx context.Context, captureFile string) error {
+	f, err := os.Open(captureFile)
+	if err != nil {
+		return err
+	}
+	records, err := idot.ReadCapture(f)
+	f.Close()
+	if err != nil {
+		return err
+	}
+
+	device, err := devOpts.Open(ctx)
+	if err != nil {
+		return err
+	}
+	defer device.Disconnect()
+
+	events := make(chan idot.Event, 16)
+	device.Notify(events)
+	defer device.StopNotify(events)
+
+	var lastTx, lastSent time.Time
+	sent := 0
+	for i, rec := range records {
+		if rec.Dir != idot.CaptureTx {
+			continue
+		}
+		if keepTiming && !lastTx.IsZero() {
+			time.Sleep(rec.Time.Sub(lastTx) - time.Since(lastSent))
+		}
+		lastTx = rec.Time
+
+		// Drop replies to earlier packets
+		for len(events) > 0 {
+			<-events
+		}
+		lastSent = time.Now()
+		if err := device.Write(rec.Data); err != nil {
+			return fmt.Errorf("packet %d: %w", sent+1, err)
+		}
+		sent++
+
+		if expectsReply(records, i) {
+			if err := waitForReply(events); err != nil {
+				return fmt.Errorf("packet %d: %w", sent, err)
+			}
+		}
+	}
+	fmt.Printf("Sent %d packets\n", sent)
+	return nil
+}
+
+func waitForReply(events <-chan idot.Event) error {
+	timer := time.NewTimer(replyTimeout)
+	defer timer.Stop()
+	for {
+		select {
+		case ev := <-events:
+			switch ev.Kind {
+			case idot.EventStateChange:
+			case idot.EventError:
+				return ev.Err()
+			default:
+				return nil
+			}
+		case <-timer.C:
+			return fmt.Errorf("no reply from display after %v", replyTimeout)
+		}
+	}
+}
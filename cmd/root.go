@@ -24,10 +24,21 @@ package cmd
 import (
 	"os"
 
+	"github.com/nj-designs/go-idot/cmd/brightness"
 	"github.com/nj-designs/go-idot/cmd/btscan"
+	"github.com/nj-designs/go-idot/cmd/countdown"
+	"github.com/nj-designs/go-idot/cmd/decode"
+	"github.com/nj-designs/go-idot/cmd/effect"
+	"github.com/nj-designs/go-idot/cmd/fill"
+	"github.com/nj-designs/go-idot/cmd/replay"
+	"github.com/nj-designs/go-idot/cmd/scoreboard"
+	"github.com/nj-designs/go-idot/cmd/screen"
 	"github.com/nj-designs/go-idot/cmd/showclock"
+	"github.com/nj-designs/go-idot/cmd/showgif"
 	"github.com/nj-designs/go-idot/cmd/showimage"
+	"github.com/nj-designs/go-idot/cmd/showtext"
 	"github.com/nj-designs/go-idot/cmd/startserver"
+	"github.com/nj-designs/go-idot/cmd/stopwatch"
 	"github.com/spf13/cobra"
 )
 
@@ -44,8 +55,19 @@ func Execute() {
 }
 
 func init() {
+	rootCmd.AddCommand(brightness.Cmd)
 	rootCmd.AddCommand(btscan.Cmd)
+	rootCmd.AddCommand(countdown.Cmd)
+	rootCmd.AddCommand(decode.Cmd)
+	rootCmd.AddCommand(effect.Cmd)
+	rootCmd.AddCommand(fill.Cmd)
+	rootCmd.AddCommand(replay.Cmd)
+	rootCmd.AddCommand(scoreboard.Cmd)
+	rootCmd.AddCommand(screen.Cmd)
 	rootCmd.AddCommand(showclock.Cmd)
+	rootCmd.AddCommand(showgif.Cmd)
 	rootCmd.AddCommand(showimage.Cmd)
+	rootCmd.AddCommand(showtext.Cmd)
 	rootCmd.AddCommand(startserver.Cmd)
+	rootCmd.AddCommand(stopwatch.Cmd)
 }
@@ -0,0 +1,79 @@
+/*
+Copyright © 2024 Neil Johnson <nj.designs@protonmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package screen
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/nj-designs/go-idot/cmd/devopts"
+	"github.com/nj-designs/go-idot/idot"
+	"github.com/spf13/cobra"
+)
+
+var devOpts devopts.Options
+
+var Cmd = &cobra.Command{
+	Use:       "screen on|off|freeze",
+	Short:     "Turns the iDot display on or off, or freezes the current frame",
+	Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	ValidArgs: []string{"on", "off", "freeze"},
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := doScreen(cmd.Context(), args[0]); err != nil {
+			fmt.Printf("error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	devOpts.AddFlags(Cmd)
+}
+
+// Action returns the Device method implementing a screen action
+func Action(device *idot.Device, action string) (func() error, error) {
+	switch action {
+	case "on":
+		return device.ScreenOn, nil
+	case "off":
+		return device.ScreenOff, nil
+	case "freeze":
+		return device.Freeze, nil
+	default:
+		return nil, fmt.Errorf("invalid screen action %q", action)
+	}
+}
+
+func doScreen(ctx context.Context, action string) error {
+	device, err := devOpts.Open(ctx)
+	if err != nil {
+		return err
+	}
+	defer device.Disconnect()
+
+	fn, err := Action(device, action)
+	if err != nil {
+		return err
+	}
+	return fn()
+}
@@ -22,52 +22,103 @@ THE SOFTWARE.
 package showclock
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 	"time"
 
+	"github.com/nj-designs/go-idot/cmd/devopts"
 	"github.com/nj-designs/go-idot/idot"
 	"github.com/spf13/cobra"
 )
 
-var clockStyle int
+var clockStyle string
 var showDate bool
 var show24h bool
 var colour string
 var timeValue string
-var targetAddr string
+var timezone string
+var syncInterval time.Duration
+var devOpts devopts.Options
 
 var Cmd = &cobra.Command{
 	Use:   "showclock",
 	Short: "Shows and optionally configures the clock of the iDot display",
 	Run: func(cmd *cobra.Command, args []string) {
-		if err := doSetClock(); err != nil {
+		if err := doSetClock(cmd.Context()); err != nil {
 			fmt.Printf("error: %v\n", err)
+			os.Exit(1)
 		}
 	},
 }
 
 func init() {
-	Cmd.Flags().StringVar(&targetAddr, "target", "", "Target iDot display MAC address")
-	Cmd.MarkFlagRequired("target")
+	devOpts.AddFlags(Cmd)
 	Cmd.Flags().StringVar(&timeValue, "time", "", "Time value in RFC1123Z format. As per 'date -R'")
-	Cmd.Flags().IntVar(&clockStyle, "style", idot.ClockAnimatedHourGlass, "Style of clock. 0:Default 1:Christmas 2:Racing 3:Inverted 4:Hour Glass")
+	Cmd.Flags().StringVar(&timezone, "timezone", "", "IANA time zone to show the time in, e.g. Europe/London. Defaults to the local time zone")
+	Cmd.Flags().DurationVar(&syncInterval, "sync-interval", 0, "Keep running and re-sync the clock at this interval and after daylight saving changes, e.g. 1h")
+	Cmd.Flags().StringVar(&clockStyle, "style", idot.ClockAnimatedHourGlass.String(), fmt.Sprintf("Style of clock. One of %s, or its number 0-%d", strings.Join(idot.ClockStyleNames(), ", "), len(idot.ClockStyleNames())-1))
 	Cmd.Flags().BoolVar(&showDate, "show-date", true, "Show date as well as time")
 	Cmd.Flags().BoolVar(&show24h, "24hour", true, "Show time in 24 hour format")
-	Cmd.Flags().StringVar(&colour, "colour", "", "Set RGB colour of clock. Format: R,G,B (0-255)")
+	Cmd.Flags().StringVar(&colour, "colour", "", "Set RGB colour of clock. Format: R,G,B (0-255). Defaults to white")
 }
 
-func doSetClock() error {
-	if len(targetAddr) == 0 {
-		return fmt.Errorf("missing --target option")
+// Options builds the ClockOptions from the sub command's arguments
+func Options(style string, showDate bool, show24h bool, colour string) (idot.ClockOptions, error) {
+	opts := idot.DefaultClockOptions
+	opts.ShowDate = showDate
+	opts.Hour24 = show24h
+
+	var err error
+	if len(style) > 0 {
+		if opts.Style, err = idot.ParseClockStyle(style); err != nil {
+			return opts, err
+		}
 	}
+	if len(colour) > 0 {
+		if opts.Colour, err = idot.ColourFromString(colour); err != nil {
+			return opts, err
+		}
+	}
+	return opts, nil
+}
 
-	if clockStyle > idot.ClockAnimatedHourGlass {
-		return fmt.Errorf("invalid style")
+// Location returns the named IANA time zone. An empty name means the local
+// time zone.
+func Location(name string) (*time.Location, error) {
+	if len(name) == 0 {
+		return time.Local, nil
+	}
+	return time.LoadLocation(name)
+}
 
+// LogSync reports failed time syncs
+func LogSync(t time.Time, err error) {
+	if err != nil {
+		fmt.Printf("Time sync failed: %v\n", err)
+	}
+}
+
+func doSetClock(ctx context.Context) error {
+	opts, err := Options(clockStyle, showDate, show24h, colour)
+	if err != nil {
+		return err
+	}
+	loc, err := Location(timezone)
+	if err != nil {
+		return err
+	}
+	if syncInterval < 0 {
+		return fmt.Errorf("invalid sync interval %v", syncInterval)
+	}
+	if syncInterval > 0 && len(timeValue) > 0 {
+		return fmt.Errorf("--time can't be used with --sync-interval")
 	}
 
 	var t time.Time
-	var err error
 
 	if len(timeValue) > 0 {
 		t, err = time.Parse(time.RFC1123Z, timeValue)
@@ -78,23 +129,26 @@ func doSetClock() error {
 		t = time.Now()
 	}
 
-	device, err := idot.NewDevice(targetAddr)
+	devOpts.Supervise = syncInterval > 0
+	device, err := devOpts.Open(ctx)
 	if err != nil {
 		return err
 	}
-	if err := device.Connect(); err != nil {
-		return err
-	}
 	defer device.Disconnect()
 
-	if err := device.SetTime(t.Year(), int(t.Month()), t.Day(), int(t.Weekday())+1, t.Hour(),
-		t.Minute(), t.Second()); err != nil {
+	if err := device.SyncTime(t.In(loc)); err != nil {
 		return err
 	}
-	customColour, err := idot.ColourFromString(colour)
-	if err := device.SetClockMode(clockStyle, showDate, show24h, customColour); err != nil {
+	if err := device.SetClock(opts); err != nil {
 		return err
 	}
+	if syncInterval == 0 {
+		return nil
+	}
 
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	fmt.Printf("Keeping time synced every %v in %s\n", syncInterval, loc)
+	device.KeepTimeSynced(ctx, idot.TimeSyncOptions{Location: loc, Interval: syncInterval, OnSync: LogSync})
 	return nil
 }
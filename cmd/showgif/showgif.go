@@ -0,0 +1,106 @@
+/*
+Copyright © 2024 Neil Johnson <nj.designs@protonmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package showgif
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image/gif"
+	"os"
+
+	"github.com/nj-designs/go-idot/cmd/devopts"
+	"github.com/nj-designs/go-idot/cmd/preview"
+	"github.com/nj-designs/go-idot/idot"
+	"github.com/spf13/cobra"
+)
+
+var devOpts devopts.Options
+var previewOpts preview.Options
+var gifFile string
+
+var Cmd = &cobra.Command{
+	Use:   "showgif",
+	Short: "Shows the supplied animated .gif file on the iDot display",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := doShowGIF(cmd.Context()); err != nil {
+			fmt.Printf("error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	devOpts.AddFlags(Cmd)
+	previewOpts.AddFlags(Cmd)
+
+	Cmd.Flags().StringVar(&gifFile, "gif-file", "", "Path to a .gif file the size of the display")
+	Cmd.MarkFlagRequired("gif-file")
+}
+
+// ValidateGIF checks gifData is a GIF the size of the display
+func ValidateGIF(gifData []byte, size idot.PanelSize) error {
+	cfg, err := gif.DecodeConfig(bytes.NewBuffer(gifData))
+	if err != nil {
+		return err
+	}
+
+	if cfg.Width != int(size) || cfg.Height != int(size) {
+		return fmt.Errorf("gif is not %s", size)
+	}
+
+	return nil
+}
+
+func doShowGIF(ctx context.Context) error {
+	if len(gifFile) == 0 {
+		return fmt.Errorf("missing --gif-file option")
+	}
+
+	gifData, err := os.ReadFile(gifFile)
+	if err != nil {
+		return err
+	}
+	if err := ValidateGIF(gifData, devOpts.Size()); err != nil {
+		return err
+	}
+	if previewOpts.Preview {
+		g, err := gif.DecodeAll(bytes.NewReader(gifData))
+		if err != nil {
+			return err
+		}
+		if err := previewOpts.ShowGIF(g); err != nil {
+			return err
+		}
+	}
+	if previewOpts.DryRun {
+		return nil
+	}
+
+	device, err := devOpts.Open(ctx)
+	if err != nil {
+		return err
+	}
+	defer device.Disconnect()
+
+	return device.SendGIF(gifData)
+}
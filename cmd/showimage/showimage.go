@@ -23,71 +23,105 @@ package showimage
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"image/png"
 	"os"
 
+	"github.com/nj-designs/go-idot/cmd/devopts"
+	"github.com/nj-designs/go-idot/cmd/preview"
 	"github.com/nj-designs/go-idot/idot"
 	"github.com/spf13/cobra"
 )
 
-var targetAddr string
+var devOpts devopts.Options
+var previewOpts preview.Options
 var imageFile string
+var fit string
+var filter string
+var background string
 
 var Cmd = &cobra.Command{
 	Use:   "showimage",
-	Short: "Shows the supplied .png file on the iDot display",
+	Short: "Shows the supplied image file on the iDot display",
 	Run: func(cmd *cobra.Command, args []string) {
-		if err := doShowImage(); err != nil {
+		if err := doShowImage(cmd.Context()); err != nil {
 			fmt.Printf("error: %v\n", err)
+			os.Exit(1)
 		}
 	},
 }
 
 func init() {
-	Cmd.Flags().StringVar(&targetAddr, "target", "", "Target iDot display MAC address")
-	Cmd.MarkFlagRequired("target")
+	devOpts.AddFlags(Cmd)
+	previewOpts.AddFlags(Cmd)
 
-	Cmd.Flags().StringVar(&imageFile, "image-file", "", "Path to a 32x32 .png image file")
+	Cmd.Flags().StringVar(&imageFile, "image-file", "", "Path to a .png, .jpg, .gif or .bmp image file. Scaled to fit the display")
 	Cmd.MarkFlagRequired("image-file")
+	Cmd.Flags().StringVar(&fit, "fit", idot.FitContain.String(), "How to fit images that aren't square. fit: letterbox, fill: crop, stretch: distort")
+	Cmd.Flags().StringVar(&filter, "filter", idot.FilterSmooth.String(), "Scaling filter. smooth or nearest (for pixel art)")
+	Cmd.Flags().StringVar(&background, "background", "", "RGB colour of letterbox and transparent areas. Format: R,G,B (0-255). Defaults to black")
 }
 
-func validateImage(imageData []byte) error {
-	pngImg, err := png.Decode(bytes.NewBuffer(imageData))
-	if err != nil {
-		return err
-	}
+// PrepareOptions converts the arguments shared by the showimage sub command
+// and REST endpoint to PrepareOptions
+func PrepareOptions(fit string, filter string, background string) (idot.PrepareOptions, error) {
+	var opts idot.PrepareOptions
+	var err error
 
-	if pngImg.Bounds().Max.X != 32 || pngImg.Bounds().Max.Y != 32 {
-		return fmt.Errorf("image is not 32x32")
+	if len(fit) > 0 {
+		if opts.Fit, err = idot.ParseFitMode(fit); err != nil {
+			return opts, err
+		}
+	}
+	if len(filter) > 0 {
+		if opts.Filter, err = idot.ParseFilter(filter); err != nil {
+			return opts, err
+		}
+	}
+	if len(background) > 0 {
+		if opts.Background, err = idot.ColourFromString(background); err != nil {
+			return opts, err
+		}
 	}
 
-	return nil
+	return opts, nil
 }
 
-func doShowImage() error {
-	if len(targetAddr) == 0 {
-		return fmt.Errorf("missing --target option")
-	}
+func doShowImage(ctx context.Context) error {
 	if len(imageFile) == 0 {
 		return fmt.Errorf("missing --image-file option")
 	}
 
+	opts, err := PrepareOptions(fit, filter, background)
+	if err != nil {
+		return err
+	}
+	opts.Size = devOpts.Size()
 	imageData, err := os.ReadFile(imageFile)
 	if err != nil {
 		return err
 	}
-	if err := validateImage(imageData); err != nil {
+	if imageData, err = idot.PrepareImage(imageData, opts); err != nil {
 		return err
 	}
+	if previewOpts.Preview {
+		img, err := png.Decode(bytes.NewReader(imageData))
+		if err != nil {
+			return err
+		}
+		if err := previewOpts.Show(img); err != nil {
+			return err
+		}
+	}
+	if previewOpts.DryRun {
+		return nil
+	}
 
-	device, err := idot.NewDevice(targetAddr)
+	device, err := devOpts.Open(ctx)
 	if err != nil {
 		return err
 	}
-	if err := device.Connect(); err != nil {
-		return err
-	}
 	defer device.Disconnect()
 
 	if err := device.SetDrawMode(1); err != nil {
@@ -0,0 +1,136 @@
+/*
+Copyright © 2024 Neil Johnson <nj.designs@protonmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package showtext
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/nj-designs/go-idot/cmd/devopts"
+	"github.com/nj-designs/go-idot/cmd/preview"
+	"github.com/nj-designs/go-idot/idot"
+	"github.com/spf13/cobra"
+)
+
+var devOpts devopts.Options
+var previewOpts preview.Options
+var text string
+var mode string
+var speed int
+var colour string
+var rainbow int
+var background string
+var fontFile string
+var fontSize float64
+
+var Cmd = &cobra.Command{
+	Use:   "showtext",
+	Short: "Shows the supplied text on the iDot display",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := doShowText(cmd.Context()); err != nil {
+			fmt.Printf("error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	devOpts.AddFlags(Cmd)
+	previewOpts.AddFlags(Cmd)
+	Cmd.Flags().StringVar(&text, "text", "", "Text to show")
+	Cmd.MarkFlagRequired("text")
+	Cmd.Flags().StringVar(&mode, "mode", idot.TextScrollLeft.String(), "Text mode. One of static, scroll-left, scroll-right, scroll-up, scroll-down, blink, breathe, snow, laser")
+	Cmd.Flags().IntVar(&speed, "speed", idot.DefaultTextOptions.Speed, "Animation speed (1-100)")
+	Cmd.Flags().StringVar(&colour, "colour", "", "Set RGB colour of text. Format: R,G,B (0-255). Defaults to white")
+	Cmd.Flags().IntVar(&rainbow, "rainbow", 0, "Use rainbow colour effect 1-4 instead of a fixed colour. 0 means off")
+	Cmd.Flags().StringVar(&background, "background", "", "Set RGB colour of background. Format: R,G,B (0-255). Defaults to black")
+	Cmd.Flags().StringVar(&fontFile, "font", "", "Path to a .ttf/.otf font. Defaults to a built in font")
+	Cmd.Flags().Float64Var(&fontSize, "font-size", 24, "Font size in pixels, when --font is used")
+}
+
+// Options converts the arguments shared by the showtext sub command and
+// REST endpoint to TextOptions
+func Options(mode string, speed int, colour string, rainbow int, background string) (idot.TextOptions, error) {
+	opts := idot.DefaultTextOptions
+	var err error
+
+	if len(mode) > 0 {
+		if opts.Mode, err = idot.ParseTextMode(mode); err != nil {
+			return opts, err
+		}
+	}
+	if speed != 0 {
+		opts.Speed = speed
+	}
+	if len(colour) > 0 {
+		if opts.Colour, err = idot.ColourFromString(colour); err != nil {
+			return opts, err
+		}
+		opts.ColourMode = idot.TextColourCustom
+	}
+	if rainbow < 0 || rainbow > 4 {
+		return opts, fmt.Errorf("invalid rainbow effect %d", rainbow)
+	}
+	if rainbow > 0 {
+		opts.ColourMode = idot.TextColourRainbow1 + idot.TextColourMode(rainbow-1)
+	}
+	if len(background) > 0 {
+		if opts.Background, err = idot.ColourFromString(background); err != nil {
+			return opts, err
+		}
+	}
+
+	return opts, nil
+}
+
+func doShowText(ctx context.Context) error {
+	opts, err := Options(mode, speed, colour, rainbow, background)
+	if err != nil {
+		return err
+	}
+	if len(fontFile) > 0 {
+		if opts.Face, err = idot.LoadFontFace(fontFile, fontSize); err != nil {
+			return err
+		}
+	}
+	if previewOpts.Preview {
+		img, err := idot.RenderText(text, opts)
+		if err != nil {
+			return err
+		}
+		if err := previewOpts.Show(img); err != nil {
+			return err
+		}
+	}
+	if previewOpts.DryRun {
+		return nil
+	}
+
+	device, err := devOpts.Open(ctx)
+	if err != nil {
+		return err
+	}
+	defer device.Disconnect()
+
+	return device.SendText(text, opts)
+}
@@ -30,19 +30,34 @@ import (
 	"os"
 	"os/signal"
 	"path"
+	"sync"
 	"syscall"
 	"time"
 
+	"github.com/nj-designs/go-idot/cmd/countdown"
+	"github.com/nj-designs/go-idot/cmd/devopts"
+	"github.com/nj-designs/go-idot/cmd/effect"
+	"github.com/nj-designs/go-idot/cmd/screen"
+	"github.com/nj-designs/go-idot/cmd/showclock"
+	"github.com/nj-designs/go-idot/cmd/showgif"
+	"github.com/nj-designs/go-idot/cmd/showimage"
+	"github.com/nj-designs/go-idot/cmd/showtext"
 	"github.com/nj-designs/go-idot/idot"
 	"github.com/spf13/cobra"
 )
 
 type iDotService struct {
-	device *idot.Device
+	device   *idot.Device
+	location *time.Location
+
+	scoreMu sync.Mutex
+	score   scoreboardValues
 }
 
 var serverPort uint
-var targetAddr string
+var timezone string
+var timeSyncInterval time.Duration
+var devOpts devopts.Options
 
 const apiBase = "/api/v1"
 
@@ -50,38 +65,73 @@ var Cmd = &cobra.Command{
 	Use:   "startserver",
 	Short: "Start a simple rest API server",
 	Run: func(cmd *cobra.Command, args []string) {
-		if err := runServer(); err != nil {
+		if err := runServer(cmd.Context()); err != nil {
 			fmt.Printf("Failed: %v\n", err)
+			os.Exit(1)
 		}
 	},
 }
 
 func init() {
 
-	Cmd.Flags().StringVar(&targetAddr, "target", "", "Target iDot display MAC address")
-	Cmd.MarkFlagRequired("target")
+	devOpts.AddFlags(Cmd)
 
 	Cmd.Flags().UintVar(&serverPort, "port", 8080, "Port to listen on")
+	Cmd.Flags().DurationVar(&devOpts.ReconnectWait, "reconnect-wait", 10*time.Second, "How long requests wait for a dropped connection to be re-established")
+	Cmd.Flags().StringVar(&timezone, "timezone", "", "IANA time zone the clock shows, e.g. Europe/London. Defaults to the local time zone")
+	Cmd.Flags().DurationVar(&timeSyncInterval, "time-sync-interval", 0, "Re-sync the display's clock at this interval and after daylight saving changes, e.g. 6h. 0 means off")
 }
 
-func runServer() error {
+func runServer(ctx context.Context) error {
+
+	loc, err := showclock.Location(timezone)
+	if err != nil {
+		return err
+	}
+	if timeSyncInterval < 0 {
+		return fmt.Errorf("invalid time sync interval %v", timeSyncInterval)
+	}
 
-	device, err := idot.NewDevice(targetAddr)
+	device, err := devOpts.NewDevice(ctx)
 	if err != nil {
 		return err
 	}
-	fmt.Printf("Connecting to %s\n", targetAddr)
-	if err := device.Connect(); err != nil {
+	fmt.Printf("Found %s Name:%s RSSI:%d\n", device.Address(), device.Name(), device.RSSI())
+	fmt.Printf("Connecting to %s\n", devOpts.Target)
+	devOpts.Supervise = true
+	if err := devOpts.Connect(device); err != nil {
 		return err
 	}
 	defer device.Disconnect()
 	fmt.Println("Connected")
 
-	ids := &iDotService{device: device}
+	events := make(chan idot.Event, 16)
+	device.Notify(events)
+	defer device.StopNotify(events)
+	go logStateChanges(events)
+
+	ids := &iDotService{device: device, location: loc}
+
+	if timeSyncInterval > 0 {
+		syncCtx, stopSync := context.WithCancel(ctx)
+		defer stopSync()
+		go device.KeepTimeSynced(syncCtx, idot.TimeSyncOptions{Location: loc, Interval: timeSyncInterval, OnSync: showclock.LogSync})
+	}
 
 	mux := http.NewServeMux()
 	mux.HandleFunc(fmt.Sprintf("POST %s", formFullUrl("/showclock/")), ids.handleShowClock)
 	mux.HandleFunc(fmt.Sprintf("POST %s", formFullUrl("/showimage/")), ids.handleShowImage)
+	mux.HandleFunc(fmt.Sprintf("POST %s", formFullUrl("/showgif/")), ids.handleShowGIF)
+	mux.HandleFunc(fmt.Sprintf("POST %s", formFullUrl("/showtext/")), ids.handleShowText)
+	mux.HandleFunc(fmt.Sprintf("POST %s", formFullUrl("/brightness/")), ids.handleBrightness)
+	mux.HandleFunc(fmt.Sprintf("POST %s", formFullUrl("/screen/{action}")), ids.handleScreen)
+	mux.HandleFunc(fmt.Sprintf("POST %s", formFullUrl("/countdown/{action}")), ids.handleCountdown)
+	mux.HandleFunc(fmt.Sprintf("POST %s", formFullUrl("/stopwatch/{action}")), ids.handleStopwatch)
+	mux.HandleFunc(fmt.Sprintf("POST %s", formFullUrl("/fill/")), ids.handleFill)
+	mux.HandleFunc(fmt.Sprintf("POST %s", formFullUrl("/effect/")), ids.handleEffect)
+	mux.HandleFunc(fmt.Sprintf("GET %s", formFullUrl("/scoreboard/")), ids.handleGetScoreboard)
+	mux.HandleFunc(fmt.Sprintf("POST %s", formFullUrl("/scoreboard/")), ids.handleScoreboard)
+	mux.HandleFunc(fmt.Sprintf("POST %s", formFullUrl("/scoreboard/{side}")), ids.handleScoreboardIncrement)
 
 	srv := &http.Server{Addr: fmt.Sprintf(":%d", serverPort), Handler: mux}
 
@@ -107,16 +157,32 @@ func runServer() error {
 	return nil
 }
 
+// logStateChanges reports the connection to the display dropping and recovering
+func logStateChanges(events <-chan idot.Event) {
+	for ev := range events {
+		if ev.Kind == idot.EventStateChange {
+			fmt.Printf("Connection %s\n", ev.State)
+		}
+	}
+}
+
+// deviceFor returns the display for use by req. Commands are dropped from
+// the queue if the client goes away before they are sent.
+func (ids *iDotService) deviceFor(req *http.Request) *idot.Device {
+	return ids.device.WithContext(req.Context())
+}
+
 func formFullUrl(endPoint string) string {
 	return path.Join(apiBase, endPoint)
 }
 
 type setClockValues struct {
 	Time     string `json:"time,omitempty"`
-	Style    int    `json:"style,omitempty"`
+	Timezone string `json:"timezone,omitempty"`
+	Style    any    `json:"style,omitempty"` // name or number
 	ShowDate bool   `json:"showdate,omitempty"`
 	Show24h  bool   `json:"show24h,omitempty"`
-	Colour  string   `json:"colour,omitempty"`
+	Colour   string `json:"colour,omitempty"`
 }
 
 func (ids *iDotService) handleShowClock(w http.ResponseWriter, req *http.Request) {
@@ -127,9 +193,24 @@ func (ids *iDotService) handleShowClock(w http.ResponseWriter, req *http.Request
 			return
 		}
 	}
-	// fmt.Println(cv)
+	style := ""
+	if cv.Style != nil {
+		style = fmt.Sprint(cv.Style)
+	}
+	opts, err := showclock.Options(style, cv.ShowDate, cv.Show24h, cv.Colour)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	loc := ids.location
+	if len(cv.Timezone) > 0 {
+		if loc, err = time.LoadLocation(cv.Timezone); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
 	var t time.Time
-	var err error
 
 	if len(cv.Time) > 0 {
 		t, err = time.Parse(time.RFC1123Z, cv.Time)
@@ -140,13 +221,13 @@ func (ids *iDotService) handleShowClock(w http.ResponseWriter, req *http.Request
 	} else {
 		t = time.Now()
 	}
-	if err := ids.device.SetTime(t.Year(), int(t.Month()), t.Day(), int(t.Weekday())+1, t.Hour(),
-		t.Minute(), t.Second()); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-	customColour, err := idot.ColourFromString(cv.Colour)
-	if err := ids.device.SetClockMode(cv.Style, cv.ShowDate, cv.Show24h, customColour); err != nil {
+	err = ids.deviceFor(req).Do(func(d *idot.Device) error {
+		if err := d.SyncTime(t.In(loc)); err != nil {
+			return err
+		}
+		return d.SetClock(opts)
+	})
+	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
@@ -168,11 +249,269 @@ func (ids *iDotService) handleShowImage(w http.ResponseWriter, req *http.Request
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	if err := ids.device.SetDrawMode(1); err != nil {
+	opts, err := showimage.PrepareOptions(req.FormValue("fit"), req.FormValue("filter"), req.FormValue("background"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if fileData, err = ids.device.PrepareImage(fileData, opts); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	err = ids.deviceFor(req).Do(func(d *idot.Device) error {
+		if err := d.SetDrawMode(1); err != nil {
+			return err
+		}
+		return d.SendImage(fileData)
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+}
+
+func (ids *iDotService) handleShowGIF(w http.ResponseWriter, req *http.Request) {
+	req.ParseMultipartForm(1024 * 1024)
+	file, handler, err := req.FormFile("giffile")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+	fmt.Printf("Uploaded File: %+v\n", handler.Filename)
+	fmt.Printf("File Size: %+v\n", handler.Size)
+	fileData, err := io.ReadAll(file)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := showgif.ValidateGIF(fileData, ids.device.PanelSize()); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := ids.deviceFor(req).SendGIF(fileData); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+}
+
+type setBrightnessValues struct {
+	Percent int `json:"percent"`
+}
+
+func (ids *iDotService) handleBrightness(w http.ResponseWriter, req *http.Request) {
+	bv := &setBrightnessValues{}
+	if err := json.NewDecoder(req.Body).Decode(bv); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := ids.deviceFor(req).SetBrightness(bv.Percent); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+}
+
+func (ids *iDotService) handleScreen(w http.ResponseWriter, req *http.Request) {
+	fn, err := screen.Action(ids.deviceFor(req), req.PathValue("action"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err := fn(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+}
+
+type countdownValues struct {
+	Duration string `json:"duration,omitempty"`
+}
+
+func (ids *iDotService) handleCountdown(w http.ResponseWriter, req *http.Request) {
+	action, err := idot.ParseCountdownAction(req.PathValue("action"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	cv := &countdownValues{Duration: "5m"}
+	if req.ContentLength > 0 {
+		if err := json.NewDecoder(req.Body).Decode(cv); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	d, err := time.ParseDuration(cv.Duration)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	minutes, seconds, err := countdown.Split(d)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := ids.deviceFor(req).Countdown(action, minutes, seconds); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+}
+
+func (ids *iDotService) handleStopwatch(w http.ResponseWriter, req *http.Request) {
+	action, err := idot.ParseChronographAction(req.PathValue("action"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err := ids.deviceFor(req).Chronograph(action); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+}
+
+type fillValues struct {
+	Colour string `json:"colour"`
+}
+
+func (ids *iDotService) handleFill(w http.ResponseWriter, req *http.Request) {
+	fv := &fillValues{}
+	if err := json.NewDecoder(req.Body).Decode(fv); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	c, err := idot.ColourFromString(fv.Colour)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := ids.deviceFor(req).FillColour(c); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+}
+
+type effectValues struct {
+	Style   string   `json:"style,omitempty"`
+	Colours []string `json:"colours"`
+}
+
+func (ids *iDotService) handleEffect(w http.ResponseWriter, req *http.Request) {
+	ev := &effectValues{Style: idot.EffectRainbowHorizontal.String()}
+	if err := json.NewDecoder(req.Body).Decode(ev); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	style, colours, err := effect.Options(ev.Style, ev.Colours)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := ids.deviceFor(req).SetEffect(style, colours); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+}
+
+type scoreboardValues struct {
+	Left  int `json:"left"`
+	Right int `json:"right"`
+}
+
+type setScoreboardValues struct {
+	Left  *int `json:"left,omitempty"`
+	Right *int `json:"right,omitempty"`
+}
+
+type incrementScoreValues struct {
+	By int `json:"by"`
+}
+
+func (ids *iDotService) handleGetScoreboard(w http.ResponseWriter, req *http.Request) {
+	ids.scoreMu.Lock()
+	score := ids.score
+	ids.scoreMu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(score)
+}
+
+func (ids *iDotService) handleScoreboard(w http.ResponseWriter, req *http.Request) {
+	sv := &setScoreboardValues{}
+	if req.ContentLength > 0 {
+		if err := json.NewDecoder(req.Body).Decode(sv); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	ids.updateScore(w, req, func(score *scoreboardValues) {
+		if sv.Left != nil {
+			score.Left = *sv.Left
+		}
+		if sv.Right != nil {
+			score.Right = *sv.Right
+		}
+	})
+}
+
+func (ids *iDotService) handleScoreboardIncrement(w http.ResponseWriter, req *http.Request) {
+	side := req.PathValue("side")
+	if side != "left" && side != "right" {
+		http.Error(w, fmt.Sprintf("invalid scoreboard side %q", side), http.StatusNotFound)
+		return
+	}
+	iv := &incrementScoreValues{By: 1}
+	if req.ContentLength > 0 {
+		if err := json.NewDecoder(req.Body).Decode(iv); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	ids.updateScore(w, req, func(score *scoreboardValues) {
+		if side == "left" {
+			score.Left += iv.By
+		} else {
+			score.Right += iv.By
+		}
+	})
+}
+
+// updateScore applies fn to the current score and shows the result, keeping
+// the new score only if the display accepted it. The new score is returned
+// as the response.
+func (ids *iDotService) updateScore(w http.ResponseWriter, req *http.Request, fn func(score *scoreboardValues)) {
+	ids.scoreMu.Lock()
+	defer ids.scoreMu.Unlock()
+
+	score := ids.score
+	fn(&score)
+	if err := ids.deviceFor(req).SetScoreboard(score.Left, score.Right); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ids.score = score
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(score)
+}
+
+type showTextValues struct {
+	Text       string `json:"text"`
+	Mode       string `json:"mode,omitempty"`
+	Speed      int    `json:"speed,omitempty"`
+	Colour     string `json:"colour,omitempty"`
+	Rainbow    int    `json:"rainbow,omitempty"`
+	Background string `json:"background,omitempty"`
+}
+
+func (ids *iDotService) handleShowText(w http.ResponseWriter, req *http.Request) {
+	tv := &showTextValues{}
+	if err := json.NewDecoder(req.Body).Decode(tv); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	opts, err := showtext.Options(tv.Mode, tv.Speed, tv.Colour, tv.Rainbow, tv.Background)
+	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	if err := ids.device.SendImage(fileData); err != nil {
+	if err := ids.deviceFor(req).SendText(tv.Text, opts); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
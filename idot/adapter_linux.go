@@ -0,0 +1,100 @@
+/*
+Copyright © 2024 Neil Johnson <nj.designs@protonmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idot
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+	"tinygo.org/x/bluetooth"
+)
+
+const defaultAdapterID = "hci0"
+
+// newAdapter returns the adapter with the given ID, e.g. hci1, or the
+// default adapter if id is empty
+func newAdapter(id string) (*btAdapter, error) {
+	if id == "" {
+		return &btAdapter{id: defaultAdapterID, Adapter: bluetooth.DefaultAdapter}, nil
+	}
+	return &btAdapter{id: id, Adapter: bluetooth.NewAdapter(id)}, nil
+}
+
+// watchLink returns a channel that is closed when BlueZ reports that addr
+// has disconnected from adapter, and a function to stop watching
+func watchLink(adapter *btAdapter, addr bluetooth.Address) (<-chan struct{}, func(), error) {
+	bus, err := dbus.SystemBus()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	path := dbus.ObjectPath("/org/bluez/" + adapter.id + "/dev_" + strings.ReplaceAll(addr.MAC.String(), ":", "_"))
+	matchOptions := []dbus.MatchOption{
+		dbus.WithMatchObjectPath(path),
+		dbus.WithMatchInterface("org.freedesktop.DBus.Properties"),
+		dbus.WithMatchMember("PropertiesChanged"),
+	}
+	if err := bus.AddMatchSignal(matchOptions...); err != nil {
+		return nil, nil, err
+	}
+
+	signals := make(chan *dbus.Signal, 8)
+	bus.Signal(signals)
+
+	disconnected := make(chan struct{})
+	stop := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-stop:
+				return
+			case sig := <-signals:
+				if isDisconnectSignal(sig, path) {
+					close(disconnected)
+					return
+				}
+			}
+		}
+	}()
+
+	unwatch := sync.OnceFunc(func() {
+		bus.RemoveSignal(signals)
+		bus.RemoveMatchSignal(matchOptions...)
+		close(stop)
+	})
+	return disconnected, unwatch, nil
+}
+
+// isDisconnectSignal reports whether sig says the device at path is no
+// longer connected
+func isDisconnectSignal(sig *dbus.Signal, path dbus.ObjectPath) bool {
+	if sig == nil || sig.Path != path || sig.Name != "org.freedesktop.DBus.Properties.PropertiesChanged" || len(sig.Body) < 2 {
+		return false
+	}
+	if iface, _ := sig.Body[0].(string); iface != "org.bluez.Device1" {
+		return false
+	}
+	changes, _ := sig.Body[1].(map[string]dbus.Variant)
+	connected, ok := changes["Connected"].Value().(bool)
+	return ok && !connected
+}
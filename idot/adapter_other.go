@@ -0,0 +1,91 @@
+//go:build !linux
+
+/*
+Copyright © 2024 Neil Johnson <nj.designs@protonmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package idot
+
+import (
+	"errors"
+	"sync"
+
+	"tinygo.org/x/bluetooth"
+)
+
+var ErrAdapterSelection = errors.New("selecting a Bluetooth adapter is only supported on Linux")
+
+// newAdapter returns the default adapter. Other adapters can only be
+// selected on Linux.
+func newAdapter(id string) (*btAdapter, error) {
+	if id != "" {
+		return nil, ErrAdapterSelection
+	}
+	return &btAdapter{Adapter: bluetooth.DefaultAdapter}, nil
+}
+
+// linkWatcher fans an adapter's single connect handler out to the
+// transports connected through it
+type linkWatcher struct {
+	mu    sync.Mutex
+	links map[bluetooth.Address]chan struct{}
+}
+
+var linkWatchers sync.Map // *bluetooth.Adapter -> *linkWatcher
+
+// watchLink returns a channel that is closed when addr disconnects from
+// adapter, and a function to stop watching
+func watchLink(adapter *btAdapter, addr bluetooth.Address) (<-chan struct{}, func(), error) {
+	lw := &linkWatcher{links: make(map[bluetooth.Address]chan struct{})}
+	if existing, loaded := linkWatchers.LoadOrStore(adapter.Adapter, lw); loaded {
+		lw = existing.(*linkWatcher)
+	} else {
+		adapter.SetConnectHandler(lw.handleConnect)
+	}
+
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+
+	ch := make(chan struct{})
+	lw.links[addr] = ch
+	unwatch := func() {
+		lw.mu.Lock()
+		defer lw.mu.Unlock()
+		if lw.links[addr] == ch {
+			delete(lw.links, addr)
+		}
+	}
+	return ch, unwatch, nil
+}
+
+func (lw *linkWatcher) handleConnect(device bluetooth.Device, connected bool) {
+	if connected {
+		return
+	}
+
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+
+	if ch, ok := lw.links[device.Address]; ok {
+		close(ch)
+		delete(lw.links, device.Address)
+	}
+}
@@ -0,0 +1,161 @@
+/*
+Copyright © 2024 Neil Johnson <nj.designs@protonmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idot
+
+import (
+	"fmt"
+
+	"tinygo.org/x/bluetooth"
+)
+
+const iDotServiceId = uint16(0x00fa)
+
+var iDotServiceUUID = bluetooth.New16BitUUID(iDotServiceId)
+
+const iDotWriteCharacteristicId = uint16(0xfa02)
+
+var iDotWriteCharacteristicUUID = bluetooth.New16BitUUID(iDotWriteCharacteristicId)
+
+const iDotReadCharacteristicId = uint16(0xfa03)
+
+var iDotReadCharacteristicUUID = bluetooth.New16BitUUID(iDotReadCharacteristicId)
+
+// btAdapter is a Bluetooth adapter along with the ID it was selected by
+type btAdapter struct {
+	id string
+	*bluetooth.Adapter
+}
+
+// bleTransport is the Bluetooth LE Transport
+type bleTransport struct {
+	disconnected        <-chan struct{}
+	unwatch             func()
+	btDevice            bluetooth.Device
+	writeCharacteristic bluetooth.DeviceCharacteristic
+	writeMTU            int
+	readCharacteristic  bluetooth.DeviceCharacteristic
+	readMTU             int
+}
+
+// connectAdapter opens the Transport to the display at addr, replaced in
+// tests
+var connectAdapter = func(adapter *btAdapter, addr bluetooth.Address) (Transport, error) {
+	t, err := connectBLE(adapter, addr)
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// connectBLE connects to the display at addr and discovers the iDot service
+func connectBLE(adapter *btAdapter, addr bluetooth.Address) (*bleTransport, error) {
+
+	disconnected, unwatch, err := watchLink(adapter, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	btd, err := adapter.Connect(addr, bluetooth.ConnectionParams{})
+	if err != nil {
+		unwatch()
+		return nil, err
+	}
+
+	t := &bleTransport{disconnected: disconnected, unwatch: unwatch, btDevice: btd}
+	if err := t.discover(); err != nil {
+		t.Close()
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func (t *bleTransport) discover() error {
+
+	srvcs, err := t.btDevice.DiscoverServices([]bluetooth.UUID{iDotServiceUUID})
+	if err != nil {
+		return fmt.Errorf("service discover failed")
+	}
+	if len(srvcs) == 0 {
+		return fmt.Errorf("device doesn't support %s service", iDotServiceUUID.String())
+	}
+
+	service := srvcs[0]
+
+	if !service.Is16Bit() || service.UUID().Get16Bit() != iDotServiceId {
+		return fmt.Errorf("invalid service id")
+	}
+
+	chars, err := service.DiscoverCharacteristics([]bluetooth.UUID{iDotWriteCharacteristicUUID, iDotReadCharacteristicUUID})
+	if err != nil {
+		return err
+	}
+	if len(chars) != 2 {
+		return fmt.Errorf("unexpected number of characteristics. expected 2, got %d", len(chars))
+	}
+
+	for _, ch := range chars {
+		if !ch.Is16Bit() {
+			return fmt.Errorf("invalid char type")
+		}
+		mtu, err := ch.GetMTU()
+		if err != nil {
+			return err
+		}
+		switch ch.Get16Bit() {
+		case iDotWriteCharacteristicId:
+			t.writeCharacteristic = ch
+			t.writeMTU = int(mtu)
+		case iDotReadCharacteristicId:
+			t.readCharacteristic = ch
+			t.readMTU = int(mtu)
+		default:
+			return fmt.Errorf("invalid characteristic %s", ch.UUID().String())
+		}
+	}
+
+	return nil
+}
+
+func (t *bleTransport) Write(packet []byte) error {
+	_, err := t.writeCharacteristic.WriteWithoutResponse(packet)
+	return err
+}
+
+// MTU returns the payload size of a write, i.e. the ATT MTU less its
+// 3 byte header
+func (t *bleTransport) MTU() int {
+	return max(t.writeMTU-3, 0)
+}
+
+func (t *bleTransport) Subscribe(fn func(buf []byte)) error {
+	return t.readCharacteristic.EnableNotifications(fn)
+}
+
+func (t *bleTransport) Close() error {
+	t.unwatch()
+	return t.btDevice.Disconnect()
+}
+
+func (t *bleTransport) Disconnected() <-chan struct{} {
+	return t.disconnected
+}
@@ -0,0 +1,110 @@
+/*
+Copyright © 2024 Neil Johnson <nj.designs@protonmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idot
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// Directions of a CaptureRecord
+const (
+	CaptureTx = "tx"
+	CaptureRx = "rx"
+)
+
+// CaptureRecord is a packet sent to, or notification received from, the
+// display. Capture files hold one JSON encoded record per line.
+type CaptureRecord struct {
+	Time time.Time `json:"time"`
+	Dir  string    `json:"dir"`
+	Data HexBytes  `json:"data"`
+}
+
+// HexBytes is a byte slice that encodes to JSON as a hex string
+type HexBytes []byte
+
+func (b HexBytes) MarshalText() ([]byte, error) {
+	return []byte(hex.EncodeToString(b)), nil
+}
+
+func (b *HexBytes) UnmarshalText(text []byte) error {
+	data, err := hex.DecodeString(string(text))
+	if err != nil {
+		return err
+	}
+	*b = data
+	return nil
+}
+
+// CaptureWriter writes capture records to an io.Writer. It is safe for
+// concurrent use.
+type CaptureWriter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewCaptureWriter returns a CaptureWriter writing JSON lines to w
+func NewCaptureWriter(w io.Writer) *CaptureWriter {
+	return &CaptureWriter{enc: json.NewEncoder(w)}
+}
+
+// Record writes a record of data travelling in direction dir
+func (cw *CaptureWriter) Record(dir string, data []byte) error {
+	rec := CaptureRecord{Time: time.Now(), Dir: dir, Data: append(HexBytes(nil), data...)}
+	cw.mu.Lock()
+	defer cw.mu.Unlock()
+	return cw.enc.Encode(rec)
+}
+
+// ReadCapture reads all the records in a capture file
+func ReadCapture(r io.Reader) ([]CaptureRecord, error) {
+	var records []CaptureRecord
+	dec := json.NewDecoder(bufio.NewReader(r))
+	for {
+		var rec CaptureRecord
+		if err := dec.Decode(&rec); err == io.EOF {
+			return records, nil
+		} else if err != nil {
+			return records, err
+		}
+		records = append(records, rec)
+	}
+}
+
+// SetCapture records every packet written to the display and every
+// notification received from it to cw. nil stops recording.
+func (d *Device) SetCapture(cw *CaptureWriter) {
+	d.capture.Store(cw)
+}
+
+// record adds data to the capture, if there is one. Capture errors are
+// ignored so they never affect talking to the display.
+func (d *Device) record(dir string, data []byte) {
+	if cw := d.capture.Load(); cw != nil {
+		cw.Record(dir, data)
+	}
+}
@@ -21,26 +21,114 @@ THE SOFTWARE.
 */
 package idot
 
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/nj-designs/go-idot/idot/proto"
+)
+
+// ClockStyle is the face used when the display shows the clock
+type ClockStyle uint8
+
 const (
-	ClockDefault           = iota
-	ClockChristmas         = iota
-	ClockRacing            = iota
-	ClockInverted          = iota
-	ClockAnimatedHourGlass = iota
+	ClockDefault           ClockStyle = 0
+	ClockChristmas         ClockStyle = 1
+	ClockRacing            ClockStyle = 2
+	ClockInverted          ClockStyle = 3
+	ClockAnimatedHourGlass ClockStyle = 4
+	ClockFrame1            ClockStyle = 5
+	ClockFrame2            ClockStyle = 6
+	ClockFrame3            ClockStyle = 7
 )
 
-func (d *Device) SetClockMode(style int, visibleDate bool, hour24 bool, colour Colour) error {
-	var sb uint8 = uint8(style)
-	if visibleDate {
-		sb |= 128
+var clockStyleNames = []string{"default", "christmas", "racing", "inverted", "hourglass", "frame1", "frame2", "frame3"}
+
+var ErrInvalidClockStyle = errors.New("invalid clock style")
+
+func (s ClockStyle) String() string {
+	if int(s) < len(clockStyleNames) {
+		return clockStyleNames[s]
 	}
-	if hour24 {
-		sb |= 64
+	return fmt.Sprintf("ClockStyle(%d)", s)
+}
+
+// Valid reports whether s is a style the display supports
+func (s ClockStyle) Valid() bool {
+	return int(s) < len(clockStyleNames)
+}
+
+// ParseClockStyle returns the ClockStyle named s, e.g. "hourglass". The
+// style's number, e.g. "4", is accepted too.
+func ParseClockStyle(s string) (ClockStyle, error) {
+	for i, name := range clockStyleNames {
+		if name == s {
+			return ClockStyle(i), nil
+		}
+	}
+	if n, err := strconv.Atoi(s); err == nil && n >= 0 && n < len(clockStyleNames) {
+		return ClockStyle(n), nil
 	}
-	return d.Write([]byte{8, 0, 6, 1, sb, colour.R, colour.G, colour.B})
+	return 0, fmt.Errorf("%w %q", ErrInvalidClockStyle, s)
 }
 
-func (d *Device) SetTime(year int, month int, day int, weekDay int, hour int, minute int, second int) error {
+// ClockStyleNames returns the names of all the clock styles
+func ClockStyleNames() []string {
+	return append([]string(nil), clockStyleNames...)
+}
+
+// ClockOptions configure how the clock is shown
+type ClockOptions struct {
+	Style    ClockStyle
+	ShowDate bool
+	Hour24   bool
+	// Colour of the clock face. The zero Colour means White, as a black
+	// face can't be seen.
+	Colour Colour
+}
+
+// DefaultClockOptions shows the date and a 24 hour clock in white
+var DefaultClockOptions = ClockOptions{
+	Style:    ClockDefault,
+	ShowDate: true,
+	Hour24:   true,
+	Colour:   White,
+}
+
+// SetClock switches the display to show the clock
+func (d *Device) SetClock(opts ClockOptions) error {
+	if !opts.Style.Valid() {
+		return fmt.Errorf("%w %d", ErrInvalidClockStyle, opts.Style)
+	}
+	colour := opts.Colour
+	if colour == (Colour{}) {
+		colour = White
+	}
+	return d.send(proto.Clock{
+		Style:    uint8(opts.Style),
+		ShowDate: opts.ShowDate,
+		Hour24:   opts.Hour24,
+		Colour:   proto.RGB(colour),
+	})
+}
 
-	return d.Write([]byte{11, 0, 1, 128, byte(year), byte(month), byte(day), byte(weekDay), byte(hour), byte(minute), byte(second)})
+// SetClockMode switches the display to show the clock. It is shorthand for
+// SetClock.
+func (d *Device) SetClockMode(style ClockStyle, visibleDate bool, hour24 bool, colour Colour) error {
+	return d.SetClock(ClockOptions{Style: style, ShowDate: visibleDate, Hour24: hour24, Colour: colour})
+}
+
+// SetTime sets the display's clock. weekDay is 1 for Sunday to 7 for
+// Saturday. Only the last two digits of year are sent.
+func (d *Device) SetTime(year int, month int, day int, weekDay int, hour int, minute int, second int) error {
+	return d.send(proto.Time{
+		Year:    uint8(year % 100),
+		Month:   uint8(month),
+		Day:     uint8(day),
+		Weekday: uint8(weekDay),
+		Hour:    uint8(hour),
+		Minute:  uint8(minute),
+		Second:  uint8(second),
+	})
 }
@@ -0,0 +1,105 @@
+/*
+Copyright © 2024 Neil Johnson <nj.designs@protonmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idot
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestParseClockStyle(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    ClockStyle
+		wantErr bool
+	}{
+		{in: "hourglass", want: ClockAnimatedHourGlass},
+		{in: "default", want: ClockDefault},
+		{in: "frame3", want: ClockFrame3},
+		{in: "4", want: ClockAnimatedHourGlass},
+		{in: "0", want: ClockDefault},
+		{in: "8", wantErr: true},
+		{in: "-1", wantErr: true},
+		{in: "Hourglass", wantErr: true},
+		{in: "", wantErr: true},
+	}
+	for _, tc := range tests {
+		got, err := ParseClockStyle(tc.in)
+		if tc.wantErr {
+			if !errors.Is(err, ErrInvalidClockStyle) {
+				t.Errorf("ParseClockStyle(%q) = %v, %v, want %v", tc.in, got, err, ErrInvalidClockStyle)
+			}
+			continue
+		}
+		if err != nil || got != tc.want {
+			t.Errorf("ParseClockStyle(%q) = %v, %v, want %v", tc.in, got, err, tc.want)
+		}
+	}
+}
+
+func TestSetClock(t *testing.T) {
+	tests := []struct {
+		name string
+		opts ClockOptions
+		want []byte
+	}{
+		{
+			name: "default colour is white",
+			opts: ClockOptions{Style: ClockDefault},
+			want: []byte{8, 0, 6, 1, 0, 255, 255, 255},
+		},
+		{
+			name: "colour",
+			opts: ClockOptions{Style: ClockFrame1, ShowDate: true, Colour: Colour{R: 1, G: 2, B: 3}},
+			want: []byte{8, 0, 6, 1, 5 | 128, 1, 2, 3},
+		},
+		{
+			name: "24 hour",
+			opts: ClockOptions{Style: ClockInverted, Hour24: true, Colour: Red},
+			want: []byte{8, 0, 6, 1, 3 | 64, 255, 0, 0},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rt := NewRecordingTransport()
+			d := newTestDevice(t, rt)
+			if err := d.SetClock(tc.opts); err != nil {
+				t.Fatalf("SetClock() = %v", err)
+			}
+			if got := rt.Bytes(); !bytes.Equal(got, tc.want) {
+				t.Errorf("SetClock() sent % x, want % x", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSetClockInvalidStyle(t *testing.T) {
+	rt := NewRecordingTransport()
+	d := newTestDevice(t, rt)
+	if err := d.SetClock(ClockOptions{Style: ClockStyle(8)}); !errors.Is(err, ErrInvalidClockStyle) {
+		t.Errorf("SetClock() = %v, want %v", err, ErrInvalidClockStyle)
+	}
+	if got := rt.Bytes(); len(got) != 0 {
+		t.Errorf("sent % x", got)
+	}
+}
@@ -34,6 +34,7 @@ type Colour struct {
 var Red = Colour{255, 0, 0}
 var Green = Colour{0, 255, 0}
 var Blue = Colour{0, 0, 255}
+var White = Colour{255, 255, 255}
 
 
 
@@ -42,9 +43,10 @@ func ColourFromString(rgb string) (Colour, error) {
     if len(parts) != 3 {
         return Colour{}, ErrInvalidRGB
     }
-    r, err1 := strconv.Atoi(strings.TrimSpace(parts[0]))
-    g, err2 := strconv.Atoi(strings.TrimSpace(parts[1]))
-    b, err3 := strconv.Atoi(strings.TrimSpace(parts[2]))
+    // Components must be 0-255
+    r, err1 := strconv.ParseUint(strings.TrimSpace(parts[0]), 10, 8)
+    g, err2 := strconv.ParseUint(strings.TrimSpace(parts[1]), 10, 8)
+    b, err3 := strconv.ParseUint(strings.TrimSpace(parts[2]), 10, 8)
     if err1 != nil || err2 != nil || err3 != nil {
         return Colour{}, ErrInvalidRGB
     }
@@ -0,0 +1,57 @@
+/*
+Copyright © 2024 Neil Johnson <nj.designs@protonmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idot
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestColourFromString(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Colour
+		wantErr bool
+	}{
+		{in: "255,128,0", want: Colour{255, 128, 0}},
+		{in: " 1, 2 ,3 ", want: Colour{1, 2, 3}},
+		{in: "0,0,0", want: Colour{}},
+		{in: "300,0,0", wantErr: true},
+		{in: "0,256,0", wantErr: true},
+		{in: "-1,0,0", wantErr: true},
+		{in: "1,2", wantErr: true},
+		{in: "1,2,3,4", wantErr: true},
+		{in: "red", wantErr: true},
+	}
+	for _, tc := range tests {
+		got, err := ColourFromString(tc.in)
+		if tc.wantErr {
+			if !errors.Is(err, ErrInvalidRGB) {
+				t.Errorf("ColourFromString(%q) = %v, %v, want %v", tc.in, got, err, ErrInvalidRGB)
+			}
+			continue
+		}
+		if err != nil || got != tc.want {
+			t.Errorf("ColourFromString(%q) = %v, %v, want %v", tc.in, got, err, tc.want)
+		}
+	}
+}
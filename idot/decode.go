@@ -0,0 +1,197 @@
+/*
+Copyright © 2024 Neil Johnson <nj.designs@protonmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idot
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nj-designs/go-idot/idot/proto"
+)
+
+// Field is a named value decoded from a packet
+type Field struct {
+	Name  string
+	Value string
+}
+
+// DecodedPacket is a human readable breakdown of a packet sent to the display
+type DecodedPacket struct {
+	// Command is the Device method that sends the packet, or "unknown"
+	Command string
+	// Length is the length prefix in the first two bytes
+	Length int
+	// Group and Sub are the command group and sub command, the third and
+	// fourth bytes
+	Group  uint8
+	Sub    uint8
+	Fields []Field
+	Raw    []byte
+}
+
+func (p DecodedPacket) String() string {
+	sb := new(strings.Builder)
+	fmt.Fprintf(sb, "%s (%d bytes)\n", p.Command, len(p.Raw))
+	width := len("length")
+	for _, f := range p.Fields {
+		width = max(width, len(f.Name))
+	}
+	fmt.Fprintf(sb, "  %-*s  %d\n", width, "length", p.Length)
+	fmt.Fprintf(sb, "  %-*s  %d/%d\n", width, "group", p.Group, p.Sub)
+	for _, f := range p.Fields {
+		fmt.Fprintf(sb, "  %-*s  %s\n", width, f.Name, f.Value)
+	}
+	return sb.String()
+}
+
+func (p *DecodedPacket) add(name string, format string, args ...any) {
+	p.Fields = append(p.Fields, Field{Name: name, Value: fmt.Sprintf(format, args...)})
+}
+
+// DecodePacket breaks down a packet written by Device.Write. Packets that
+// aren't recognised are decoded as far as the common header.
+func DecodePacket(packet []byte) DecodedPacket {
+	p := DecodedPacket{Command: "unknown", Raw: append([]byte(nil), packet...)}
+	if len(packet) < 4 {
+		p.add("data", "% x", packet)
+		return p
+	}
+	p.Length = int(binary.LittleEndian.Uint16(packet))
+	p.Group, p.Sub = packet[2], packet[3]
+
+	cmd, err := proto.Parse(packet)
+	if err != nil {
+		p.add("error", "%v", err)
+		p.add("data", "% x", packet[4:])
+		return p
+	}
+
+	switch c := cmd.(type) {
+	case *proto.DrawMode:
+		p.Command = "SetDrawMode"
+		p.add("mode", "%d", c.Mode)
+	case *proto.Brightness:
+		p.Command = "SetBrightness"
+		p.add("percent", "%d", c.Percent)
+	case *proto.Screen:
+		p.Command = "ScreenOff"
+		if c.On {
+			p.Command = "ScreenOn"
+		}
+	case *proto.Freeze:
+		p.Command = "Freeze"
+	case *proto.Clock:
+		p.Command = "SetClock"
+		p.add("style", "%s", ClockStyle(c.Style))
+		p.add("show date", "%t", c.ShowDate)
+		p.add("24 hour", "%t", c.Hour24)
+		p.add("colour", "%s", decodeColour(c.Colour))
+	case *proto.Time:
+		p.Command = "SetTime"
+		p.add("year", "%02d", c.Year)
+		p.add("month", "%d", c.Month)
+		p.add("day", "%d", c.Day)
+		weekDay := fmt.Sprint(c.Weekday)
+		if c.Weekday >= 1 && c.Weekday <= 7 {
+			weekDay += " (" + time.Weekday(c.Weekday-1).String() + ")"
+		}
+		p.add("weekday", "%s", weekDay)
+		p.add("time", "%02d:%02d:%02d", c.Hour, c.Minute, c.Second)
+	case *proto.Countdown:
+		p.Command = "Countdown"
+		p.add("action", "%s", CountdownAction(c.Action))
+		p.add("duration", "%d:%02d", c.Minutes, c.Seconds)
+	case *proto.Chronograph:
+		p.Command = "Chronograph"
+		p.add("action", "%s", ChronographAction(c.Action))
+	case *proto.Scoreboard:
+		p.Command = "SetScoreboard"
+		p.add("left", "%d", c.Left)
+		p.add("right", "%d", c.Right)
+	case *proto.Fill:
+		p.Command = "FillColour"
+		p.add("colour", "%s", decodeColour(c.Colour))
+	case *proto.Effect:
+		p.Command = "SetEffect"
+		p.add("style", "%s", EffectStyle(c.Style))
+		p.add("speed", "%d", c.Speed)
+		for i, rgb := range c.Colours {
+			p.add(fmt.Sprintf("colour %d", i+1), "%s", decodeColour(rgb))
+		}
+	case *proto.Pixel:
+		p.Command = "SetPixel"
+		p.add("colour", "%s", decodeColour(c.Colour))
+		p.add("x,y", "%d,%d", c.X, c.Y)
+	case *proto.ImageChunk:
+		p.Command = "SendImage"
+		decodeChunkFlag(&p, c.Continuation)
+		p.add("image size", "%d", c.Total)
+		p.add("data", "%d bytes", len(c.Data))
+	case *proto.GIFChunk:
+		p.Command = "SendGIF"
+		decodeChunkFlag(&p, c.Continuation)
+		p.add("gif size", "%d", c.Total)
+		p.add("gif crc32", "%08x", c.CRC)
+		p.add("data", "%d bytes", len(c.Data))
+	case *proto.Text:
+		p.Command = "SendText"
+		p.add("characters", "%d", len(c.Glyphs))
+		p.add("mode", "%s", TextMode(c.Mode))
+		p.add("speed", "%d", c.Speed)
+		p.add("colour mode", "%d", c.ColourMode)
+		p.add("colour", "%s", decodeColour(c.Colour))
+		p.add("background", "%t %s", c.BackgroundOn, decodeColour(c.Background))
+	}
+	return p
+}
+
+func decodeColour(rgb proto.RGB) string {
+	return fmt.Sprintf("%d,%d,%d", rgb.R, rgb.G, rgb.B)
+}
+
+func decodeChunkFlag(p *DecodedPacket, continuation bool) {
+	if continuation {
+		p.add("chunk", "continuation")
+	} else {
+		p.add("chunk", "first")
+	}
+}
+
+// DecodeNotification breaks down a notification received from the display
+func DecodeNotification(buf []byte) DecodedPacket {
+	p := DecodedPacket{Command: "unknown", Raw: append([]byte(nil), buf...)}
+	if len(buf) >= 4 {
+		p.Length = int(binary.LittleEndian.Uint16(buf))
+		p.Group, p.Sub = buf[2], buf[3]
+	}
+	ev := ParseEvent(buf)
+	if ev.Kind == EventUnknown {
+		p.add("data", "% x", buf)
+		return p
+	}
+	p.Command = "Reply"
+	p.add("event", "%s", ev.Kind)
+	p.add("status", "%d", ev.Code)
+	return p
+}
@@ -22,130 +22,381 @@ THE SOFTWARE.
 package idot
 
 import (
+	"context"
+	"encoding"
+	"errors"
 	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"tinygo.org/x/bluetooth"
 )
 
-const iDotServiceId = uint16(0x00fa)
+// defaultWriteSize is used when the transport can't report its MTU
+const defaultWriteSize = 514
 
-var iDotServiceUUID = bluetooth.New16BitUUID(iDotServiceId)
+// DefaultScanTimeout is how long NewDevice scans for a display
+const DefaultScanTimeout = 30 * time.Second
 
-const iDotWriteCharacteristicId = uint16(0xfa02)
+var ErrNotConnected = errors.New("device is not connected")
 
-var iDotWriteCharacteristicUUID = bluetooth.New16BitUUID(iDotWriteCharacteristicId)
+var ErrDeviceNotFound = errors.New("device not found")
 
-const iDotReadCharacteristicId = uint16(0xfa03)
+var ErrReconnecting = errors.New("device is reconnecting")
 
-var iDotReadCharacteristicUUID = bluetooth.New16BitUUID(iDotReadCharacteristicId)
+// DeviceOptions control how a display is found
+type DeviceOptions struct {
+	// ScanTimeout bounds how long to scan for the display. 0 means
+	// DefaultScanTimeout.
+	ScanTimeout time.Duration
+	// AdapterID selects the Bluetooth adapter to use, e.g. hci1. Empty
+	// means the default adapter.
+	AdapterID string
+	// PanelSize is the size of the display. 0 means DefaultPanelSize.
+	PanelSize PanelSize
+}
+
+// ConnectOptions tune how packets are written to the display
+type ConnectOptions struct {
+	// WriteSize overrides the negotiated MTU as the maximum number of bytes
+	// sent per write. 0 means use the MTU.
+	WriteSize int
+	// WriteDelay is the minimum time between consecutive writes, for
+	// adapters that drop data when written to back to back.
+	WriteDelay time.Duration
 
-var btAdapter = bluetooth.DefaultAdapter
+	// Supervise enables automatic reconnection when the link drops
+	Supervise bool
+	// ReconnectMinBackoff and ReconnectMaxBackoff bound the exponential
+	// backoff between reconnect attempts. 0 means 1s and 1m respectively.
+	ReconnectMinBackoff time.Duration
+	ReconnectMaxBackoff time.Duration
+	// ReconnectWait is how long a write issued while reconnecting waits for
+	// the link to come back before failing with ErrReconnecting. 0 means
+	// fail immediately.
+	ReconnectWait time.Duration
+}
 
+// Device is an iDot display. It is safe for concurrent use: commands are
+// queued and sent one at a time, highest Priority first, so packets from
+// different goroutines are never interleaved.
 type Device struct {
-	scanResult          bluetooth.ScanResult
-	btDevice            *bluetooth.Device
-	writeCharacteristic bluetooth.DeviceCharacteristic
-	writeMTU            int
-	readCharacteristic  bluetooth.DeviceCharacteristic
-	readMTU             int
+	*device
+
+	// Set on views of the device returned by WithContext and WithPriority,
+	// and on the view passed to a running command
+	ctx       context.Context
+	prio      Priority
+	exclusive bool
 }
 
-func NewDevice(targetAddr string) (*Device, error) {
-	d := &Device{}
+// device is the state shared by a Device and its views
+type device struct {
+	display   Display
+	panelSize atomic.Int64 // PanelSize, read outside the queue by PanelSize
+	dial      func() (Transport, error)
+	opts      ConnectOptions
+	lastWrite time.Time
+	writes    int // count of Write calls, used to detect writes between Flushes
+
+	lastFrame       *Framebuffer
+	lastFrameWrites int
+
+	connMu    sync.Mutex
+	transport Transport
+	writeSize int
+	state     ConnState
+	ready     chan struct{} // closed when a reconnect completes or is abandoned
 
-	if err := btAdapter.Enable(); err != nil {
-		return d, err
+	stop       chan struct{}
+	linkLost   chan Transport
+	supervisor sync.WaitGroup
+
+	mu        sync.Mutex
+	listeners map[chan<- Event]struct{}
+
+	capture atomic.Pointer[CaptureWriter]
+
+	queue commandQueue
+}
+
+// NewDevice scans for the display identified by target, giving up after
+// DefaultScanTimeout. See NewDeviceContext for the forms target can take.
+func NewDevice(target string) (*Device, error) {
+	return NewDeviceContext(context.Background(), target, DeviceOptions{})
+}
+
+// NewDeviceContext scans for the display identified by target. The scan
+// stops when ctx is done or opts.ScanTimeout expires, in which case
+// ErrDeviceNotFound is returned.
+//
+// target is one of: a MAC address; an advertised name such as IDM-825058,
+// which may contain path.Match wildcards (e.g. IDM-*); or TargetAuto to
+// pick the display with the strongest signal.
+func NewDeviceContext(ctx context.Context, target string, opts DeviceOptions) (*Device, error) {
+	timeout := opts.ScanTimeout
+	if timeout <= 0 {
+		timeout = DefaultScanTimeout
 	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
 
-	err := btAdapter.Scan(func(adapter *bluetooth.Adapter, result bluetooth.ScanResult) {
-		// println("found device:", result.Address.String(), result.RSSI, result.LocalName())
-		if result.Address.String() == targetAddr {
-			adapter.StopScan()
-			d.scanResult = result
-		}
-	})
+	if opts.PanelSize != 0 && !opts.PanelSize.Valid() {
+		return nil, ErrInvalidPanelSize
+	}
+
+	adapter, err := newAdapter(opts.AdapterID)
 	if err != nil {
-		// Scanning didn't start, just return
-		return d, err
+		return nil, err
+	}
+
+	var disp Display
+	found := false
+
+	if target == TargetAuto {
+		// Scan with the adapter that will connect, so it has been enabled
+		displays, err := discover(ctx, adapter, min(timeout, DefaultDiscoveryTime))
+		if err != nil && ctx.Err() == nil {
+			return nil, err
+		}
+		if len(displays) > 0 {
+			disp = displays[0]
+			found = true
+		}
+	} else {
+		match := matchTarget(target)
+		err := scanAdapter(ctx, adapter.Adapter, func(result bluetooth.ScanResult) bool {
+			if match(result) {
+				disp = newDisplay(result)
+				found = true
+			}
+			return found
+		})
+		if err != nil && ctx.Err() == nil {
+			// Scanning didn't start, just return
+			return nil, err
+		}
 	}
 
+	if !found {
+		if errors.Is(ctx.Err(), context.Canceled) {
+			return nil, fmt.Errorf("%w: %s: %w", ErrDeviceNotFound, target, ctx.Err())
+		}
+		return nil, fmt.Errorf("%w: %s", ErrDeviceNotFound, target)
+	}
+
+	d := &Device{device: &device{display: disp}}
+	d.panelSize.Store(int64(opts.PanelSize))
+	d.dial = func() (Transport, error) {
+		return connectAdapter(adapter, disp.address)
+	}
 	return d, nil
 }
 
+// NewDeviceWithTransport returns a Device that talks to the display over t
+// instead of Bluetooth. Connect must still be called before use.
+func NewDeviceWithTransport(t Transport) *Device {
+	return NewDeviceWithDialer(func() (Transport, error) {
+		return t, nil
+	})
+}
+
+// NewDeviceWithDialer returns a Device that calls dial to open a new
+// Transport each time it connects or reconnects
+func NewDeviceWithDialer(dial func() (Transport, error)) *Device {
+	return &Device{device: &device{dial: dial}}
+}
+
+// Address returns the MAC address of the display found by the scan
+func (d *Device) Address() string {
+	return d.display.Address
+}
+
+// Name returns the advertised local name of the display
+func (d *Device) Name() string {
+	return d.display.Name
+}
+
+// RSSI returns the signal strength seen when the display was found
+func (d *Device) RSSI() int16 {
+	return d.display.RSSI
+}
+
 func (d *Device) Connect() error {
+	return d.ConnectWithOptions(ConnectOptions{})
+}
+
+// ConnectWithOptions connects to the display using opts
+func (d *Device) ConnectWithOptions(opts ConnectOptions) error {
 
-	btd, err := btAdapter.Connect(d.scanResult.Address, bluetooth.ConnectionParams{})
+	d.setState(StateConnecting)
+	t, err := d.open()
 	if err != nil {
+		d.setState(StateDisconnected)
 		return err
 	}
+	d.opts = opts
+	d.attach(t)
 
-	srvcs, err := btd.DiscoverServices([]bluetooth.UUID{iDotServiceUUID})
-	if err != nil {
-		return fmt.Errorf("service discover failed")
+	if opts.Supervise {
+		d.stop = make(chan struct{})
+		d.linkLost = make(chan Transport, 1)
+		d.supervisor.Add(1)
+		go d.supervise(t)
 	}
-	if len(srvcs) == 0 {
-		return fmt.Errorf("device doesn't support %s service", iDotServiceUUID.String())
-	}
-
-	service := srvcs[0]
 
-	if !service.Is16Bit() || service.UUID().Get16Bit() != iDotServiceId {
-		return fmt.Errorf("invalid service id")
-	}
+	return nil
+}
 
-	chars, err := service.DiscoverCharacteristics([]bluetooth.UUID{iDotWriteCharacteristicUUID, iDotReadCharacteristicUUID})
+// open dials the display and subscribes to its notifications
+func (d *Device) open() (Transport, error) {
+	t, err := d.dial()
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if len(chars) != 2 {
-		return fmt.Errorf("unexpected number of characteristics. expected 2, got %d", len(chars))
+	if err := t.Subscribe(d.handleNotification); err != nil {
+		t.Close()
+		return nil, fmt.Errorf("failed to subscribe to notifications: %w", err)
 	}
+	return t, nil
+}
 
-	for _, ch := range chars {
-		if !ch.Is16Bit() {
-			return fmt.Errorf("invalid char type")
-		}
-		mtu, err := ch.GetMTU()
-		if err != nil {
-			return err
-		}
-		switch ch.Get16Bit() {
-		case iDotWriteCharacteristicId:
-			d.writeCharacteristic = ch
-			d.writeMTU = int(mtu)
-		case iDotReadCharacteristicId:
-			d.readCharacteristic = ch
-			d.readMTU = int(mtu)
-		default:
-			return fmt.Errorf("invalid characteristic %s", ch.UUID().String())
-		}
+// attach makes t the transport used for writes
+func (d *Device) attach(t Transport) {
+	writeSize := d.opts.WriteSize
+	if writeSize <= 0 {
+		writeSize = t.MTU()
+	}
+	if writeSize <= 0 {
+		writeSize = defaultWriteSize
 	}
 
-	d.btDevice = btd
+	d.connMu.Lock()
+	d.transport = t
+	d.writeSize = writeSize
+	d.wakeLocked()
+	d.connMu.Unlock()
 
-	return nil
+	d.setState(StateConnected)
 }
 
-func (d *Device) Disconnect() {
-	d.btDevice.Disconnect()
+func (d *Device) Disconnect() error {
+	if d.stop != nil {
+		close(d.stop)
+		d.supervisor.Wait()
+		d.stop = nil
+		d.linkLost = nil
+	}
+
+	d.connMu.Lock()
+	t := d.transport
+	d.transport = nil
+	d.wakeLocked()
+	d.connMu.Unlock()
+
+	d.setState(StateDisconnected)
+	if t == nil {
+		return nil
+	}
+	return t.Close()
 }
 
 // Write will write the supplied packet to the device
 // in up to MTU sized chunks
 func (d *Device) Write(packet []byte) error {
+	return d.exec(func(d *Device) error {
+		return d.write(packet)
+	})
+}
 
+// write is Write for a command that already has exclusive use of the display
+func (d *Device) write(packet []byte) error {
+	// Check before, not during, so a packet is never cut short
+	if err := d.context().Err(); err != nil {
+		return err
+	}
+	t, writeSize, err := d.currentTransport()
+	if err != nil {
+		return err
+	}
+	d.writes++
+	d.record(CaptureTx, packet)
+
+	sent, err := d.writeSlices(t, writeSize, packet)
+	if err != nil && sent == 0 && d.linkLost != nil && d.opts.ReconnectWait > 0 {
+		// Nothing got through, so send the packet again once reconnected
+		if t, writeSize, err = d.currentTransport(); err != nil {
+			return err
+		}
+		_, err = d.writeSlices(t, writeSize, packet)
+	}
+	return err
+}
+
+// writeSlices writes packet to t in writeSize slices, returning how many
+// bytes were written
+func (d *Device) writeSlices(t Transport, writeSize int, packet []byte) (int, error) {
 	cursor := 0
 	remaining := len(packet)
 	for remaining > 0 {
-		// wl := min(d.writeMTU, remaining)
-		wl := min(514, remaining)
-		_, err := d.writeCharacteristic.WriteWithoutResponse(packet[cursor : cursor+wl])
+		wl := min(writeSize, remaining)
+		if d.opts.WriteDelay > 0 {
+			time.Sleep(d.opts.WriteDelay - time.Since(d.lastWrite))
+		}
+		err := t.Write(packet[cursor : cursor+wl])
+		d.lastWrite = time.Now()
 		if err != nil {
-			return err
+			d.linkDown(t)
+			return cursor, err
 		}
 		cursor += wl
 		remaining -= wl
 	}
 
-	return nil
+	return cursor, nil
+}
+
+// send encodes cmd and writes it to the display
+func (d *Device) send(cmd encoding.BinaryMarshaler) error {
+	packet, err := cmd.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	return d.exec(func(d *Device) error {
+		return d.write(packet)
+	})
+}
+
+// currentTransport returns the transport to write to, waiting up to
+// opts.ReconnectWait if a reconnect is in progress
+func (d *Device) currentTransport() (Transport, int, error) {
+	d.connMu.Lock()
+	t, writeSize, state, ready := d.transport, d.writeSize, d.state, d.ready
+	d.connMu.Unlock()
+
+	if t != nil {
+		return t, writeSize, nil
+	}
+	if state != StateReconnecting {
+		return nil, 0, ErrNotConnected
+	}
+	if d.opts.ReconnectWait <= 0 {
+		return nil, 0, ErrReconnecting
+	}
+
+	timer := time.NewTimer(d.opts.ReconnectWait)
+	defer timer.Stop()
+	select {
+	case <-ready:
+	case <-timer.C:
+		return nil, 0, ErrReconnecting
+	}
+
+	d.connMu.Lock()
+	defer d.connMu.Unlock()
+	if d.transport == nil {
+		return nil, 0, ErrNotConnected
+	}
+	return d.transport, d.writeSize, nil
 }
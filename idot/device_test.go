@@ -0,0 +1,148 @@
+/*
+Copyright © 2024 Neil Johnson <nj.designs@protonmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idot
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+// testPacket returns an n byte packet of counting bytes
+func testPacket(n int) []byte {
+	packet := make([]byte, n)
+	for i := range packet {
+		packet[i] = byte(i)
+	}
+	return packet
+}
+
+// packetSizes returns the length of each packet written to rt
+func packetSizes(rt *RecordingTransport) []int {
+	var sizes []int
+	for _, p := range rt.Packets() {
+		sizes = append(sizes, len(p))
+	}
+	return sizes
+}
+
+func TestWriteSlicing(t *testing.T) {
+	tests := []struct {
+		name      string
+		mtu       int
+		writeSize int
+		n         int
+		want      []int
+	}{
+		{name: "default size", n: 1200, want: []int{514, 514, 172}},
+		{name: "mtu", mtu: 20, n: 45, want: []int{20, 20, 5}},
+		{name: "exact mtu", mtu: 20, n: 40, want: []int{20, 20}},
+		{name: "smaller than mtu", mtu: 20, n: 7, want: []int{7}},
+		{name: "write size", mtu: 100, writeSize: 30, n: 70, want: []int{30, 30, 10}},
+		{name: "write size without mtu", writeSize: 8, n: 20, want: []int{8, 8, 4}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rt := &RecordingTransport{MaxPacketSize: tc.mtu}
+			d := NewDeviceWithTransport(rt)
+			if err := d.ConnectWithOptions(ConnectOptions{WriteSize: tc.writeSize}); err != nil {
+				t.Fatal(err)
+			}
+			defer d.Disconnect()
+
+			packet := testPacket(tc.n)
+			if err := d.Write(packet); err != nil {
+				t.Fatalf("Write() = %v", err)
+			}
+			if got := packetSizes(rt); !slices.Equal(got, tc.want) {
+				t.Errorf("packet sizes = %v, want %v", got, tc.want)
+			}
+			if got := rt.Bytes(); !bytes.Equal(got, packet) {
+				t.Errorf("sent % x, want % x", got, packet)
+			}
+		})
+	}
+}
+
+func TestWriteSizeOverMTU(t *testing.T) {
+	rt := &RecordingTransport{MaxPacketSize: 20}
+	d := NewDeviceWithTransport(rt)
+	if err := d.ConnectWithOptions(ConnectOptions{WriteSize: 32}); err != nil {
+		t.Fatal(err)
+	}
+	defer d.Disconnect()
+
+	if err := d.Write(testPacket(40)); !errors.Is(err, ErrPacketTooLarge) {
+		t.Errorf("Write() = %v, want %v", err, ErrPacketTooLarge)
+	}
+}
+
+func TestWriteDelay(t *testing.T) {
+	const delay = 20 * time.Millisecond
+
+	rt := &RecordingTransport{MaxPacketSize: 10}
+	var times []time.Time
+	rt.OnWrite = func(packet []byte) {
+		times = append(times, time.Now())
+	}
+	d := NewDeviceWithTransport(rt)
+	if err := d.ConnectWithOptions(ConnectOptions{WriteDelay: delay}); err != nil {
+		t.Fatal(err)
+	}
+	defer d.Disconnect()
+
+	// Pacing applies between the slices of a packet and between packets
+	if err := d.Write(testPacket(30)); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Write(testPacket(5)); err != nil {
+		t.Fatal(err)
+	}
+	if len(times) != 4 {
+		t.Fatalf("%d writes, want 4", len(times))
+	}
+	for i := 1; i < len(times); i++ {
+		if gap := times[i].Sub(times[i-1]); gap < delay {
+			t.Errorf("write %d sent %v after the one before, want at least %v", i, gap, delay)
+		}
+	}
+}
+
+func TestBLETransportMTU(t *testing.T) {
+	tests := []struct {
+		attMTU int
+		want   int
+	}{
+		{attMTU: 517, want: 514},
+		{attMTU: 23, want: 20},
+		{attMTU: 0, want: 0},
+	}
+	for _, tc := range tests {
+		bt := &bleTransport{writeMTU: tc.attMTU}
+		if got := bt.MTU(); got != tc.want {
+			t.Errorf("MTU() with ATT MTU %d = %d, want %d", tc.attMTU, got, tc.want)
+		}
+	}
+}
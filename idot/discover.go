@@ -0,0 +1,196 @@
+/*
+Copyright © 2024 Neil Johnson <nj.designs@protonmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idot
+
+import (
+	"context"
+	"errors"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"tinygo.org/x/bluetooth"
+)
+
+// TargetAuto can be passed to NewDevice in place of an address to pick the
+// display with the strongest signal
+const TargetAuto = "auto"
+
+// DefaultDiscoveryTime is how long Discover, and NewDevice with TargetAuto,
+// scan for displays
+const DefaultDiscoveryTime = 5 * time.Second
+
+// displayNamePrefix starts the advertised name of every iDotMatrix display
+const displayNamePrefix = "IDM-"
+
+// Display is an iDotMatrix display seen during a scan
+type Display struct {
+	Address string
+	Name    string
+	RSSI    int16
+
+	address bluetooth.Address
+}
+
+func newDisplay(result bluetooth.ScanResult) Display {
+	return Display{
+		Address: result.Address.String(),
+		Name:    result.LocalName(),
+		RSSI:    result.RSSI,
+		address: result.Address,
+	}
+}
+
+// isDisplay reports whether an advertisement is from an iDotMatrix display
+func isDisplay(result bluetooth.ScanResult) bool {
+	return strings.HasPrefix(result.LocalName(), displayNamePrefix) || result.HasServiceUUID(iDotServiceUUID)
+}
+
+// Discover scans for iDotMatrix displays for opts.ScanTimeout, or
+// DefaultDiscoveryTime if that is 0, and returns those seen strongest
+// signal first
+func Discover(ctx context.Context, opts DeviceOptions) ([]Display, error) {
+	adapter, err := newAdapter(opts.AdapterID)
+	if err != nil {
+		return nil, err
+	}
+	return discover(ctx, adapter, opts.ScanTimeout)
+}
+
+// discover is Discover using adapter
+func discover(ctx context.Context, adapter *btAdapter, timeout time.Duration) ([]Display, error) {
+	if timeout <= 0 {
+		timeout = DefaultDiscoveryTime
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	seen := make(map[string]Display)
+	err := scanAdapter(ctx, adapter.Adapter, func(result bluetooth.ScanResult) bool {
+		if isDisplay(result) {
+			disp := newDisplay(result)
+			seen[disp.Address] = disp
+		}
+		return false
+	})
+	if err != nil && ctx.Err() == nil {
+		return nil, err
+	}
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return nil, ctx.Err()
+	}
+
+	displays := make([]Display, 0, len(seen))
+	for _, disp := range seen {
+		displays = append(displays, disp)
+	}
+	sort.Slice(displays, func(i, j int) bool {
+		return displays[i].RSSI > displays[j].RSSI
+	})
+	return displays, nil
+}
+
+// Scan reports every Bluetooth device seen by the adapter selected in opts
+// to fn, displays or otherwise, until ctx is done or opts.ScanTimeout, if
+// non zero, expires
+func Scan(ctx context.Context, opts DeviceOptions, fn func(Display)) error {
+	if opts.ScanTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.ScanTimeout)
+		defer cancel()
+	}
+
+	adapter, err := newAdapter(opts.AdapterID)
+	if err != nil {
+		return err
+	}
+
+	err = scanAdapter(ctx, adapter.Adapter, func(result bluetooth.ScanResult) bool {
+		fn(newDisplay(result))
+		return false
+	})
+	if ctx.Err() != nil {
+		// Stopped by ctx, whether or not scanning had started
+		return nil
+	}
+	return err
+}
+
+// matchTarget returns a function that reports whether a scan result is the
+// display identified by target, which is either a MAC address, or an
+// advertised name that may contain path.Match wildcards
+func matchTarget(target string) func(bluetooth.ScanResult) bool {
+	if _, err := bluetooth.ParseMAC(target); err == nil {
+		return func(result bluetooth.ScanResult) bool {
+			return strings.EqualFold(result.Address.String(), target)
+		}
+	}
+	return func(result bluetooth.ScanResult) bool {
+		matched, _ := path.Match(target, result.LocalName())
+		return matched
+	}
+}
+
+// scanAdapter is the scan function used, replaced in tests
+var scanAdapter = scan
+
+// scan calls fn for each advertisement seen by adapter until fn returns
+// true or ctx is done. If ctx is done before scanning starts, ctx.Err() is
+// returned.
+func scan(ctx context.Context, adapter *bluetooth.Adapter, fn func(bluetooth.ScanResult) bool) error {
+
+	if err := adapter.Enable(); err != nil {
+		return err
+	}
+	// A scan can't be stopped before it starts, so don't start one late
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	scanDone := make(chan struct{})
+	defer close(scanDone)
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-scanDone:
+			return
+		}
+		// StopScan fails until Scan has got going, so keep trying
+		ticker := time.NewTicker(10 * time.Millisecond)
+		defer ticker.Stop()
+		for adapter.StopScan() != nil {
+			select {
+			case <-ticker.C:
+			case <-scanDone:
+				return
+			}
+		}
+	}()
+
+	return adapter.Scan(func(adapter *bluetooth.Adapter, result bluetooth.ScanResult) {
+		// println("found device:", result.Address.String(), result.RSSI, result.LocalName())
+		if fn(result) {
+			adapter.StopScan()
+		}
+	})
+}
@@ -0,0 +1,91 @@
+/*
+Copyright © 2024 Neil Johnson <nj.designs@protonmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idot
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"tinygo.org/x/bluetooth"
+)
+
+// testAdvertisement is an advertisement carrying just a local name
+type testAdvertisement struct {
+	name string
+}
+
+func (a testAdvertisement) LocalName() string                                     { return a.name }
+func (a testAdvertisement) HasServiceUUID(bluetooth.UUID) bool                    { return false }
+func (a testAdvertisement) Bytes() []byte                                         { return nil }
+func (a testAdvertisement) ManufacturerData() []bluetooth.ManufacturerDataElement { return nil }
+func (a testAdvertisement) ServiceData() []bluetooth.ServiceDataElement           { return nil }
+
+func TestAutoTargetUsesSelectedAdapter(t *testing.T) {
+	mac, err := bluetooth.ParseMAC("12:34:56:78:9A:BC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := bluetooth.Address{MACAddress: bluetooth.MACAddress{MAC: mac}}
+
+	// Only an adapter that has scanned has been enabled
+	scanned := make(map[*bluetooth.Adapter]bool)
+	defer func(scan func(context.Context, *bluetooth.Adapter, func(bluetooth.ScanResult) bool) error) {
+		scanAdapter = scan
+	}(scanAdapter)
+	scanAdapter = func(ctx context.Context, adapter *bluetooth.Adapter, fn func(bluetooth.ScanResult) bool) error {
+		scanned[adapter] = true
+		fn(bluetooth.ScanResult{Address: addr, RSSI: -40, AdvertisementPayload: testAdvertisement{name: "IDM-123456"}})
+		return nil
+	}
+
+	var connected *btAdapter
+	defer func(connect func(*btAdapter, bluetooth.Address) (Transport, error)) {
+		connectAdapter = connect
+	}(connectAdapter)
+	connectAdapter = func(adapter *btAdapter, a bluetooth.Address) (Transport, error) {
+		connected = adapter
+		if a != addr {
+			t.Errorf("connecting to %s, want %s", a, addr)
+		}
+		return NewRecordingTransport(), nil
+	}
+
+	d, err := NewDeviceContext(context.Background(), TargetAuto, DeviceOptions{AdapterID: "hci1", ScanTimeout: time.Second})
+	if err != nil {
+		t.Fatalf("NewDeviceContext() = %v", err)
+	}
+	if d.Name() != "IDM-123456" {
+		t.Errorf("Name() = %q, want IDM-123456", d.Name())
+	}
+	if err := d.Connect(); err != nil {
+		t.Fatalf("Connect() = %v", err)
+	}
+	defer d.Disconnect()
+
+	if connected == nil || connected.id != "hci1" {
+		t.Fatalf("connected with adapter %v, want hci1", connected)
+	}
+	if !scanned[connected.Adapter] {
+		t.Errorf("connected with an adapter that wasn't enabled by a scan")
+	}
+}
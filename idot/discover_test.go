@@ -0,0 +1,100 @@
+/*
+Copyright © 2024 Neil Johnson <nj.designs@protonmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idot
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"tinygo.org/x/bluetooth"
+)
+
+// fakeScan stands in for scan, seeing nothing until ctx is done
+func fakeScan(ctx context.Context, adapter *bluetooth.Adapter, fn func(bluetooth.ScanResult) bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	<-ctx.Done()
+	return nil
+}
+
+func TestNewDeviceNotFound(t *testing.T) {
+	defer func(scan func(context.Context, *bluetooth.Adapter, func(bluetooth.ScanResult) bool) error) {
+		scanAdapter = scan
+	}(scanAdapter)
+	scanAdapter = fakeScan
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		target  string
+		timeout time.Duration
+		cause   error
+	}{
+		{name: "cancelled", ctx: cancelled, target: "IDM-*", timeout: time.Second, cause: context.Canceled},
+		{name: "cancelled auto", ctx: cancelled, target: TargetAuto, timeout: time.Second, cause: context.Canceled},
+		{name: "timeout", ctx: context.Background(), target: "IDM-*", timeout: 10 * time.Millisecond},
+		{name: "timeout auto", ctx: context.Background(), target: TargetAuto, timeout: 10 * time.Millisecond},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			done := make(chan error, 1)
+			go func() {
+				_, err := NewDeviceContext(tc.ctx, tc.target, DeviceOptions{ScanTimeout: tc.timeout})
+				done <- err
+			}()
+
+			select {
+			case err := <-done:
+				if !errors.Is(err, ErrDeviceNotFound) {
+					t.Errorf("NewDeviceContext() = %v, want %v", err, ErrDeviceNotFound)
+				}
+				if tc.cause != nil && !errors.Is(err, tc.cause) {
+					t.Errorf("NewDeviceContext() = %v, want it to wrap %v", err, tc.cause)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("NewDeviceContext() didn't return")
+			}
+		})
+	}
+}
+
+func TestScanStoppedByContext(t *testing.T) {
+	defer func(scan func(context.Context, *bluetooth.Adapter, func(bluetooth.ScanResult) bool) error) {
+		scanAdapter = scan
+	}(scanAdapter)
+	scanAdapter = fakeScan
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := Scan(cancelled, DeviceOptions{}, func(Display) {}); err != nil {
+		t.Errorf("Scan() with cancelled context = %v", err)
+	}
+	if err := Scan(context.Background(), DeviceOptions{ScanTimeout: 10 * time.Millisecond}, func(Display) {}); err != nil {
+		t.Errorf("Scan() = %v", err)
+	}
+}
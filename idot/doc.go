@@ -22,5 +22,7 @@ THE SOFTWARE.
 
 /*
 Package idot implements the protocol required to configure iDot displays via bluetooth
+
+The packets themselves are encoded and decoded by the proto sub package.
 */
 package idot
@@ -0,0 +1,92 @@
+/*
+Copyright © 2024 Neil Johnson <nj.designs@protonmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idot
+
+import (
+	"fmt"
+
+	"github.com/nj-designs/go-idot/idot/proto"
+)
+
+const (
+	MinEffectColours = 2
+	MaxEffectColours = 7
+)
+
+// EffectStyle is one of the display's built in animations
+type EffectStyle uint8
+
+const (
+	EffectRainbowHorizontal    EffectStyle = 0
+	EffectRandomPixels         EffectStyle = 1
+	EffectRandomWhitePixels    EffectStyle = 2
+	EffectRainbowVertical      EffectStyle = 3
+	EffectRainbowDiagonalRight EffectStyle = 4
+	EffectRainbowDiagonalLeft  EffectStyle = 5
+	EffectRandomColourPixels   EffectStyle = 6
+)
+
+var effectStyleNames = []string{"rainbow-horizontal", "random-pixels", "random-white-pixels", "rainbow-vertical", "rainbow-diagonal-right", "rainbow-diagonal-left", "random-colour-pixels"}
+
+func (s EffectStyle) String() string {
+	if int(s) < len(effectStyleNames) {
+		return effectStyleNames[s]
+	}
+	return fmt.Sprintf("EffectStyle(%d)", s)
+}
+
+// ParseEffectStyle returns the EffectStyle named s, e.g. "rainbow-vertical"
+func ParseEffectStyle(s string) (EffectStyle, error) {
+	for i, name := range effectStyleNames {
+		if name == s {
+			return EffectStyle(i), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid effect style %q", s)
+}
+
+// EffectStyleNames returns the names of all the effect styles
+func EffectStyleNames() []string {
+	return append([]string(nil), effectStyleNames...)
+}
+
+// FillColour sets the whole display to a single colour
+func (d *Device) FillColour(c Colour) error {
+	return d.send(proto.Fill{Colour: proto.RGB(c)})
+}
+
+// SetEffect shows one of the display's built in animations using between
+// MinEffectColours and MaxEffectColours colours
+func (d *Device) SetEffect(style EffectStyle, colours []Colour) error {
+	if int(style) >= len(effectStyleNames) {
+		return fmt.Errorf("invalid effect style %d", style)
+	}
+	if len(colours) < MinEffectColours || len(colours) > MaxEffectColours {
+		return fmt.Errorf("effect needs between %d and %d colours, got %d", MinEffectColours, MaxEffectColours, len(colours))
+	}
+	// Based on Effect.setMode in core/idotmatrix/effect.py
+	cmd := proto.Effect{Style: uint8(style), Speed: proto.EffectSpeed}
+	for _, c := range colours {
+		cmd.Colours = append(cmd.Colours, proto.RGB(c))
+	}
+	return d.send(cmd)
+}
@@ -0,0 +1,158 @@
+/*
+Copyright © 2024 Neil Johnson <nj.designs@protonmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idot
+
+import (
+	"fmt"
+
+	"github.com/nj-designs/go-idot/idot/proto"
+)
+
+// EventKind identifies the type of a notification sent by the display
+type EventKind int
+
+const (
+	EventUnknown        EventKind = iota
+	EventChunkAck       EventKind = iota
+	EventUploadComplete EventKind = iota
+	EventError          EventKind = iota
+	EventStateChange    EventKind = iota
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case EventChunkAck:
+		return "chunk-ack"
+	case EventUploadComplete:
+		return "upload-complete"
+	case EventError:
+		return "error"
+	case EventStateChange:
+		return "state-change"
+	default:
+		return "unknown"
+	}
+}
+
+// Command groups the display replies to
+const (
+	GroupGIF   = proto.GroupGIF
+	GroupImage = proto.GroupImage
+	GroupText  = proto.GroupText
+)
+
+// Event is a decoded notification from the display's read (0xfa03)
+// characteristic, or a change in the state of the link to it
+type Event struct {
+	Kind  EventKind
+	Group uint8 // command group the reply relates to
+	Code  uint8 // raw status code
+	Raw   []byte
+	State ConnState // new link state, for EventStateChange
+}
+
+// Err returns a *ResponseError if the event reports a failure, nil otherwise
+func (e Event) Err() error {
+	if e.Kind != EventError {
+		return nil
+	}
+	return &ResponseError{Group: e.Group, Code: e.Code}
+}
+
+func (e Event) String() string {
+	if e.Kind == EventStateChange {
+		return fmt.Sprintf("%s %s", e.Kind, e.State)
+	}
+	return fmt.Sprintf("%s group:%d code:%d raw:% x", e.Kind, e.Group, e.Code, e.Raw)
+}
+
+// ResponseError is returned when the display reports that a command failed
+type ResponseError struct {
+	Group uint8
+	Code  uint8
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("display reported error %d for command group %d", e.Code, e.Group)
+}
+
+// ParseEvent decodes a notification received from the display.
+// Replies look like: len(2 bytes LE) group 0 status
+func ParseEvent(buf []byte) Event {
+	ev := Event{Kind: EventUnknown, Raw: append([]byte(nil), buf...)}
+
+	var reply proto.Reply
+	if reply.UnmarshalBinary(buf) != nil {
+		return ev
+	}
+	ev.Group = reply.Group
+	ev.Code = reply.Status
+
+	switch ev.Code {
+	case proto.StatusNext:
+		ev.Kind = EventChunkAck
+	case proto.StatusComplete:
+		ev.Kind = EventUploadComplete
+	case proto.StatusFailed, proto.StatusRejected:
+		ev.Kind = EventError
+	}
+	return ev
+}
+
+// Notify causes decoded notifications from the display to be relayed to ch.
+// Sends on ch do not block, so ch should be buffered; events are dropped
+// if it is full.
+func (d *Device) Notify(ch chan<- Event) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.listeners == nil {
+		d.listeners = make(map[chan<- Event]struct{})
+	}
+	d.listeners[ch] = struct{}{}
+}
+
+// StopNotify stops relaying events to ch
+func (d *Device) StopNotify(ch chan<- Event) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.listeners, ch)
+}
+
+// handleNotification is the Transport subscription callback
+func (d *Device) handleNotification(buf []byte) {
+	d.record(CaptureRx, buf)
+	d.publish(ParseEvent(buf))
+}
+
+func (d *Device) publish(ev Event) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for ch := range d.listeners {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
@@ -0,0 +1,243 @@
+/*
+Copyright © 2024 Neil Johnson <nj.designs@protonmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idot
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+
+	"github.com/nj-designs/go-idot/idot/proto"
+)
+
+// Framebuffer is an in-memory frame that can be drawn in to and then shown
+// on the display with Device.Flush. It implements draw.Image so the
+// standard image/draw functions can be used on it too.
+type Framebuffer struct {
+	size PanelSize
+	pix  []Colour
+}
+
+// NewFramebuffer returns a black Framebuffer for a display of the given size
+func NewFramebuffer(size PanelSize) *Framebuffer {
+	size = size.orDefault()
+	return &Framebuffer{size: size, pix: make([]Colour, int(size)*int(size))}
+}
+
+// Size returns the size of display the Framebuffer is for
+func (fb *Framebuffer) Size() PanelSize {
+	return fb.size
+}
+
+func (fb *Framebuffer) ColorModel() color.Model {
+	return color.RGBAModel
+}
+
+func (fb *Framebuffer) Bounds() image.Rectangle {
+	return image.Rect(0, 0, int(fb.size), int(fb.size))
+}
+
+func (fb *Framebuffer) At(x int, y int) color.Color {
+	c := fb.Pixel(x, y)
+	return color.RGBA{c.R, c.G, c.B, 255}
+}
+
+func (fb *Framebuffer) Set(x int, y int, c color.Color) {
+	rgba := color.RGBAModel.Convert(c).(color.RGBA)
+	fb.SetPixel(x, y, Colour{rgba.R, rgba.G, rgba.B})
+}
+
+// Pixel returns the colour of the pixel at x, y. Pixels outside the frame
+// are black.
+func (fb *Framebuffer) Pixel(x int, y int) Colour {
+	if !(image.Point{x, y}).In(fb.Bounds()) {
+		return Colour{}
+	}
+	return fb.pix[y*int(fb.size)+x]
+}
+
+// SetPixel sets the pixel at x, y to c. Pixels outside the frame are ignored.
+func (fb *Framebuffer) SetPixel(x int, y int, c Colour) {
+	if !(image.Point{x, y}).In(fb.Bounds()) {
+		return
+	}
+	fb.pix[y*int(fb.size)+x] = c
+}
+
+// Clear sets every pixel to c
+func (fb *Framebuffer) Clear(c Colour) {
+	for i := range fb.pix {
+		fb.pix[i] = c
+	}
+}
+
+// FillRect sets every pixel in r to c
+func (fb *Framebuffer) FillRect(r image.Rectangle, c Colour) {
+	r = r.Intersect(fb.Bounds())
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			fb.pix[y*int(fb.size)+x] = c
+		}
+	}
+}
+
+// Line draws a line from x0, y0 to x1, y1 inclusive
+func (fb *Framebuffer) Line(x0 int, y0 int, x1 int, y1 int, c Colour) {
+	// Bresenham's line algorithm
+	dx := abs(x1 - x0)
+	dy := -abs(y1 - y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	e := dx + dy
+	for {
+		fb.SetPixel(x0, y0, c)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			e += dx
+			y0 += sy
+		}
+	}
+}
+
+// Blit draws img with its top left corner at p, blending any transparency
+// over the existing pixels
+func (fb *Framebuffer) Blit(img image.Image, p image.Point) {
+	r := image.Rectangle{Min: p, Max: p.Add(img.Bounds().Size())}
+	draw.Draw(fb, r, img, img.Bounds().Min, draw.Over)
+}
+
+// Clone returns a copy of fb
+func (fb *Framebuffer) Clone() *Framebuffer {
+	return &Framebuffer{size: fb.size, pix: append([]Colour(nil), fb.pix...)}
+}
+
+// PNG encodes the frame as the PNG expected by SendImage
+func (fb *Framebuffer) PNG() ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if err := png.Encode(buf, fb); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// graffitiPacketLen is the length of the packet that sets a single pixel
+const graffitiPacketLen = 10
+
+// imageUploadOverhead approximates the extra bytes sent by a full frame
+// upload, over the size of the PNG itself
+const imageUploadOverhead = 5 + 9
+
+// Flush shows fb on the display. The first flush, and any flush after
+// something else has been written to the display, sends the whole frame
+// as an image. After that only the pixels that changed are sent, one
+// graffiti command each, unless sending the whole frame is smaller.
+func (d *Device) Flush(fb *Framebuffer) error {
+	return d.exec(func(d *Device) error {
+		return d.flush(fb)
+	})
+}
+
+// flush is Flush for a command that already has exclusive use of the display
+func (d *Device) flush(fb *Framebuffer) error {
+	if fb.size != d.PanelSize() {
+		return fmt.Errorf("framebuffer is %s, display is %s", fb.size, d.PanelSize())
+	}
+
+	var changed []image.Point
+	if d.lastFrame != nil && d.writes == d.lastFrameWrites {
+		for i, c := range fb.pix {
+			if c != d.lastFrame.pix[i] {
+				changed = append(changed, image.Point{i % int(fb.size), i / int(fb.size)})
+			}
+		}
+		if len(changed) == 0 {
+			return nil
+		}
+	}
+
+	full := changed == nil
+	var pngData []byte
+	if !full && len(changed)*graffitiPacketLen > 512 {
+		// Only worth encoding the frame when the diff is sizeable
+		var err error
+		if pngData, err = fb.PNG(); err != nil {
+			return err
+		}
+		full = len(pngData)+imageUploadOverhead < len(changed)*graffitiPacketLen
+	}
+
+	if full {
+		if pngData == nil {
+			var err error
+			if pngData, err = fb.PNG(); err != nil {
+				return err
+			}
+		}
+		if err := d.SetDrawMode(1); err != nil {
+			return err
+		}
+		if err := d.SendImage(pngData); err != nil {
+			return err
+		}
+	} else {
+		for _, p := range changed {
+			if err := d.SetPixel(p.X, p.Y, fb.Pixel(p.X, p.Y)); err != nil {
+				return err
+			}
+		}
+	}
+
+	d.lastFrame = fb.Clone()
+	d.lastFrameWrites = d.writes
+	return nil
+}
+
+// SetPixel sets a single pixel on the display using a graffiti command.
+// Only makes sense after a call to SetDrawMode(1)
+func (d *Device) SetPixel(x int, y int, c Colour) error {
+	if x < 0 || y < 0 || x >= int(d.PanelSize()) || y >= int(d.PanelSize()) {
+		return fmt.Errorf("pixel %d,%d is outside the display", x, y)
+	}
+	return d.send(proto.Pixel{Colour: proto.RGB(c), X: uint8(x), Y: uint8(y)})
+}
@@ -0,0 +1,168 @@
+/*
+Copyright © 2024 Neil Johnson <nj.designs@protonmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idot
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"reflect"
+	"testing"
+
+	"github.com/nj-designs/go-idot/idot/proto"
+)
+
+// flushDevice returns a Device that writes each command to rt as a single
+// packet and acknowledges uploads
+func flushDevice(t *testing.T) (*Device, *RecordingTransport) {
+	t.Helper()
+	rt := &RecordingTransport{MaxPacketSize: 2 * proto.ChunkSize}
+	ackEveryWrite(rt, GroupImage, proto.StatusNext)
+	return newTestDevice(t, rt), rt
+}
+
+// sentCommands decodes the packets written to rt
+func sentCommands(t *testing.T, rt *RecordingTransport) []proto.Command {
+	t.Helper()
+	var cmds []proto.Command
+	for _, p := range rt.Packets() {
+		cmd, err := proto.Parse(p)
+		if err != nil {
+			t.Fatalf("sent % x: %v", p, err)
+		}
+		cmds = append(cmds, cmd)
+	}
+	return cmds
+}
+
+// checkFullUpload checks cmds switch to draw mode 1 and upload fb
+func checkFullUpload(t *testing.T, cmds []proto.Command, fb *Framebuffer) {
+	t.Helper()
+	if len(cmds) != 2 {
+		t.Fatalf("sent %d commands, want draw mode and an image: %+v", len(cmds), cmds)
+	}
+	if want := (&proto.DrawMode{Mode: 1}); !reflect.DeepEqual(cmds[0], want) {
+		t.Errorf("first command = %+v, want %+v", cmds[0], want)
+	}
+	chunk, ok := cmds[1].(*proto.ImageChunk)
+	if !ok {
+		t.Fatalf("second command = %T, want *proto.ImageChunk", cmds[1])
+	}
+	img, err := png.Decode(bytes.NewReader(chunk.Data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for y := 0; y < int(fb.Size()); y++ {
+		for x := 0; x < int(fb.Size()); x++ {
+			r, g, b, _ := img.At(x, y).RGBA()
+			if got := (Colour{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8)}); got != fb.Pixel(x, y) {
+				t.Fatalf("uploaded pixel %d,%d = %v, want %v", x, y, got, fb.Pixel(x, y))
+			}
+		}
+	}
+}
+
+func TestFlushFirstFrame(t *testing.T) {
+	d, rt := flushDevice(t)
+	fb := NewFramebuffer(DefaultPanelSize)
+	fb.SetPixel(1, 2, Colour{R: 255})
+
+	if err := d.Flush(fb); err != nil {
+		t.Fatalf("Flush() = %v", err)
+	}
+	checkFullUpload(t, sentCommands(t, rt), fb)
+}
+
+func TestFlushChangedPixels(t *testing.T) {
+	d, rt := flushDevice(t)
+	fb := NewFramebuffer(DefaultPanelSize)
+	if err := d.Flush(fb); err != nil {
+		t.Fatal(err)
+	}
+	rt.Reset()
+
+	fb.SetPixel(1, 2, Colour{R: 255})
+	fb.SetPixel(30, 31, Colour{G: 10, B: 20})
+	if err := d.Flush(fb); err != nil {
+		t.Fatalf("Flush() = %v", err)
+	}
+	want := []proto.Command{
+		&proto.Pixel{Colour: proto.RGB{R: 255}, X: 1, Y: 2},
+		&proto.Pixel{Colour: proto.RGB{G: 10, B: 20}, X: 30, Y: 31},
+	}
+	if got := sentCommands(t, rt); !reflect.DeepEqual(got, want) {
+		t.Errorf("sent %+v, want %+v", got, want)
+	}
+
+	// Nothing has changed since
+	rt.Reset()
+	if err := d.Flush(fb); err != nil {
+		t.Fatalf("Flush() = %v", err)
+	}
+	if got := rt.Packets(); len(got) != 0 {
+		t.Errorf("unchanged frame sent %d packets", len(got))
+	}
+}
+
+func TestFlushAfterWrite(t *testing.T) {
+	d, rt := flushDevice(t)
+	fb := NewFramebuffer(DefaultPanelSize)
+	if err := d.Flush(fb); err != nil {
+		t.Fatal(err)
+	}
+
+	// Anything else sent may have changed what's shown
+	if err := d.FillColour(Colour{B: 255}); err != nil {
+		t.Fatal(err)
+	}
+	rt.Reset()
+	if err := d.Flush(fb); err != nil {
+		t.Fatalf("Flush() = %v", err)
+	}
+	checkFullUpload(t, sentCommands(t, rt), fb)
+}
+
+func TestFlushLargeDiff(t *testing.T) {
+	d, rt := flushDevice(t)
+	fb := NewFramebuffer(DefaultPanelSize)
+	if err := d.Flush(fb); err != nil {
+		t.Fatal(err)
+	}
+	rt.Reset()
+
+	// A PNG of one colour is far smaller than a pixel per packet
+	fb.FillRect(image.Rect(0, 0, 32, 32), Colour{R: 10, G: 20, B: 30})
+	if err := d.Flush(fb); err != nil {
+		t.Fatalf("Flush() = %v", err)
+	}
+	checkFullUpload(t, sentCommands(t, rt), fb)
+}
+
+func TestFlushWrongSize(t *testing.T) {
+	d, rt := flushDevice(t)
+	if err := d.Flush(NewFramebuffer(Panel16)); err == nil {
+		t.Error("Flush() of a 16x16 framebuffer to a 32x32 display succeeded")
+	}
+	if got := rt.Packets(); len(got) != 0 {
+		t.Errorf("sent %d packets", len(got))
+	}
+}
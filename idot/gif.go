@@ -0,0 +1,45 @@
+/*
+Copyright © 2024 Neil Johnson <nj.designs@protonmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idot
+
+import (
+	"github.com/nj-designs/go-idot/idot/proto"
+)
+
+// SendGIF sends an animated GIF the size of the display to it
+func (d *Device) SendGIF(gifData []byte) error {
+	return d.SendGIFWithOptions(gifData, DefaultUploadOptions)
+}
+
+// SendGIFWithOptions is SendGIF with control over chunk pacing
+func (d *Device) SendGIFWithOptions(gifData []byte, opts UploadOptions) error {
+	payloads, err := marshalChunks(proto.GIFChunks(gifData))
+	if err != nil {
+		return err
+	}
+	return d.exec(func(d *Device) error {
+		if err := d.checkImageSize(gifData); err != nil {
+			return err
+		}
+		return d.upload(payloads, GroupGIF, opts)
+	})
+}
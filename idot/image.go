@@ -22,49 +22,144 @@ THE SOFTWARE.
 package idot
 
 import (
-	"bytes"
-	"encoding/binary"
+	"context"
+	"encoding"
+	"errors"
+	"time"
+
+	"github.com/nj-designs/go-idot/idot/proto"
 )
 
+// UploadOptions control how chunked uploads are paced
+type UploadOptions struct {
+	// AckTimeout is how long to wait for the display to acknowledge a chunk.
+	// 0 means DefaultUploadOptions.AckTimeout.
+	AckTimeout time.Duration
+	// Retries is the number of times a chunk is resent after a timeout or
+	// an error reply before the upload is abandoned
+	Retries int
+	// Progress, if set, is called after each chunk has been acknowledged
+	Progress func(sent int, total int)
+}
+
+// DefaultUploadOptions are used by SendImage
+var DefaultUploadOptions = UploadOptions{
+	AckTimeout: 5 * time.Second,
+	Retries:    2,
+}
+
+var ErrAckTimeout = errors.New("timed out waiting for chunk acknowledgement")
+
 // SetDrawMode sends set draw mode to display
 func (d *Device) SetDrawMode(mode int) error {
-	return d.Write([]byte{5, 0, 4, 1, uint8(mode)})
+	return d.send(proto.DrawMode{Mode: uint8(mode)})
 }
 
-// SendImage sends an image to the display. Only makes sense after a call to SetDrawMode(1)
+// SendImage sends a PNG image the size of the display to it. Use PrepareImage
+// to scale other images. Only makes sense after a call to SetDrawMode(1)
 func (d *Device) SendImage(imageData []byte) error {
+	return d.SendImageWithOptions(imageData, DefaultUploadOptions)
+}
+
+// SendImageWithOptions is SendImage with control over chunk pacing
+func (d *Device) SendImageWithOptions(imageData []byte, opts UploadOptions) error {
+	payloads, err := marshalChunks(proto.ImageChunks(imageData))
+	if err != nil {
+		return err
+	}
+	return d.exec(func(d *Device) error {
+		if err := d.checkImageSize(imageData); err != nil {
+			return err
+		}
+		return d.upload(payloads, GroupImage, opts)
+	})
+}
+
+// marshalChunks encodes each chunk of an upload
+func marshalChunks[T encoding.BinaryMarshaler](chunks []T) ([][]byte, error) {
+	payloads := make([][]byte, 0, len(chunks))
+	for _, ch := range chunks {
+		payload, err := ch.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		payloads = append(payloads, payload)
+	}
+	return payloads, nil
+}
+
+// upload writes each payload in turn, waiting for the display to acknowledge
+// it with a reply for group before moving on to the next. Unacknowledged
+// payloads are resent up to opts.Retries times. The caller must have
+// exclusive use of the display.
+func (d *Device) upload(payloads [][]byte, group uint8, opts UploadOptions) error {
+	timeout := opts.AckTimeout
+	if timeout <= 0 {
+		timeout = DefaultUploadOptions.AckTimeout
+	}
 
-	// Based on create_payloads in core/idotmatrix/image.py
-	chunks := chunkBuffer(imageData, 4096)
-	cib := new(bytes.Buffer)
-	idk := len(imageData) + len(chunks)
-	for ci, ch := range chunks {
-		binary.Write(cib, binary.LittleEndian, uint16(idk)) //struct.pack("h", idk)
-		binary.Write(cib, binary.LittleEndian, uint8(0))
-		binary.Write(cib, binary.LittleEndian, uint8(0))
-		if ci > 0 {
-			binary.Write(cib, binary.LittleEndian, uint8(2))
-		} else {
-			binary.Write(cib, binary.LittleEndian, uint8(0))
+	events := make(chan Event, 16)
+	d.Notify(events)
+	defer d.StopNotify(events)
+
+	for pi, payload := range payloads {
+		var err error
+		for attempt := 0; attempt <= opts.Retries; attempt++ {
+			drainEvents(events)
+			if err = d.write(payload); err != nil {
+				return err
+			}
+			if err = waitForAck(d.context(), events, group, timeout); err == nil {
+				break
+			}
+			if ctxErr := d.context().Err(); ctxErr != nil {
+				return ctxErr
+			}
+		}
+		if err != nil {
+			return err
+		}
+		if opts.Progress != nil {
+			opts.Progress(pi+1, len(payloads))
 		}
-		binary.Write(cib, binary.LittleEndian, int32(len(imageData))) // struct.pack("i", len(png_data))
-		binary.Write(cib, binary.LittleEndian, ch)
 	}
 
-	return d.Write(cib.Bytes())
+	return nil
 }
 
-// chunkBuffer chunks the supplied data buffer to chunkSize slices
-func chunkBuffer(data []byte, chunkSize int) [][]byte {
-	chunks := make([][]byte, 0)
-
-	cursor := 0
-	remaining := len(data)
-	for remaining > 0 {
-		wl := min(chunkSize, remaining)
-		chunks = append(chunks, data[cursor:cursor+wl])
-		cursor += wl
-		remaining -= wl
+// waitForAck waits for the display to acknowledge the last chunk sent.
+// Replies for other command groups are ignored.
+func waitForAck(ctx context.Context, events <-chan Event, group uint8, timeout time.Duration) error {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case ev := <-events:
+			if ev.Group != group {
+				continue
+			}
+			switch ev.Kind {
+			case EventChunkAck, EventUploadComplete:
+				return nil
+			case EventError:
+				return ev.Err()
+			}
+		case <-timer.C:
+			return ErrAckTimeout
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// drainEvents discards any stale events
+func drainEvents(events <-chan Event) {
+	for {
+		select {
+		case <-events:
+		default:
+			return
+		}
 	}
-	return chunks
 }
@@ -0,0 +1,276 @@
+/*
+Copyright © 2024 Neil Johnson <nj.designs@protonmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idot
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"math/rand"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/nj-designs/go-idot/idot/proto"
+)
+
+// noisePNG returns an uncompressed 32x32 PNG of noise, which is split in to
+// two chunks when uploaded
+func noisePNG(t *testing.T) []byte {
+	t.Helper()
+	src := image.NewRGBA(image.Rect(0, 0, 32, 32))
+	rnd := rand.New(rand.NewSource(1))
+	for i := range src.Pix {
+		src.Pix[i] = uint8(rnd.Intn(256))
+	}
+	var buf bytes.Buffer
+	if err := (&png.Encoder{CompressionLevel: png.NoCompression}).Encode(&buf, src); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() <= proto.ChunkSize || buf.Len() > 2*proto.ChunkSize {
+		t.Fatalf("test image is %d bytes", buf.Len())
+	}
+	return buf.Bytes()
+}
+
+// replyTo makes rt answer the n'th packet written to it, counting from 0,
+// with the reply returned by fn. No reply is sent if fn returns nil.
+func replyTo(rt *RecordingTransport, fn func(n int) *proto.Reply) {
+	n := 0
+	rt.OnWrite = func(packet []byte) {
+		reply := fn(n)
+		n++
+		if reply != nil {
+			buf, _ := reply.MarshalBinary()
+			rt.Notify(buf)
+		}
+	}
+}
+
+// chunkFlags returns the continuation flag of each image chunk written to rt
+func chunkFlags(rt *RecordingTransport) []byte {
+	var flags []byte
+	for _, p := range rt.Packets() {
+		flags = append(flags, p[4])
+	}
+	return flags
+}
+
+func TestUploadAcks(t *testing.T) {
+	img := noisePNG(t)
+
+	rt := &RecordingTransport{MaxPacketSize: 2 * proto.ChunkSize}
+	written := make(chan int, 2)
+	ack, _ := proto.Reply{Group: GroupImage, Status: proto.StatusNext}.MarshalBinary()
+	rt.OnWrite = func(packet []byte) {
+		written <- len(rt.Packets())
+	}
+	d := newTestDevice(t, rt)
+
+	var progress [][2]int
+	opts := DefaultUploadOptions
+	opts.Progress = func(sent int, total int) {
+		progress = append(progress, [2]int{sent, total})
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- d.SendImageWithOptions(img, opts)
+	}()
+
+	// Each chunk is only sent once the one before it is acknowledged
+	for want := 1; want <= 2; want++ {
+		if n := <-written; n != want {
+			t.Fatalf("%d packets written before ack %d", n, want)
+		}
+		select {
+		case n := <-written:
+			t.Fatalf("packet %d written before ack", n)
+		case <-time.After(50 * time.Millisecond):
+		}
+		rt.Notify(ack)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("SendImageWithOptions() = %v", err)
+	}
+	if want := []byte{0, 2}; !bytes.Equal(chunkFlags(rt), want) {
+		t.Errorf("chunk flags = %v, want %v", chunkFlags(rt), want)
+	}
+	if want := [][2]int{{1, 2}, {2, 2}}; !slices.Equal(progress, want) {
+		t.Errorf("progress = %v, want %v", progress, want)
+	}
+}
+
+func TestUploadRetries(t *testing.T) {
+	img := noisePNG(t)
+	ack := &proto.Reply{Group: GroupImage, Status: proto.StatusNext}
+	failed := &proto.Reply{Group: GroupImage, Status: proto.StatusFailed}
+	wrongGroup := &proto.Reply{Group: GroupGIF, Status: proto.StatusNext}
+
+	tests := []struct {
+		name    string
+		retries int
+		reply   func(n int) *proto.Reply
+		flags   []byte
+		wantErr error
+	}{
+		{
+			name:    "resend on timeout",
+			retries: 2,
+			reply: func(n int) *proto.Reply {
+				if n == 0 {
+					return nil
+				}
+				return ack
+			},
+			flags: []byte{0, 0, 2},
+		},
+		{
+			name:    "resend on error reply",
+			retries: 2,
+			reply: func(n int) *proto.Reply {
+				if n == 1 {
+					return failed
+				}
+				return ack
+			},
+			flags: []byte{0, 2, 2},
+		},
+		{
+			name:    "timeout after retries",
+			retries: 1,
+			reply: func(n int) *proto.Reply {
+				return nil
+			},
+			flags:   []byte{0, 0},
+			wantErr: ErrAckTimeout,
+		},
+		{
+			name:    "error reply",
+			retries: 0,
+			reply: func(n int) *proto.Reply {
+				return failed
+			},
+			flags:   []byte{0},
+			wantErr: &ResponseError{Group: GroupImage, Code: proto.StatusFailed},
+		},
+		{
+			name:    "ack for another upload",
+			retries: 0,
+			reply: func(n int) *proto.Reply {
+				return wrongGroup
+			},
+			flags:   []byte{0},
+			wantErr: ErrAckTimeout,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rt := &RecordingTransport{MaxPacketSize: 2 * proto.ChunkSize}
+			replyTo(rt, tc.reply)
+			d := newTestDevice(t, rt)
+
+			progress := 0
+			err := d.SendImageWithOptions(img, UploadOptions{
+				AckTimeout: 20 * time.Millisecond,
+				Retries:    tc.retries,
+				Progress:   func(sent int, total int) { progress = sent },
+			})
+			var respErr *ResponseError
+			switch want := tc.wantErr.(type) {
+			case nil:
+				if err != nil {
+					t.Fatalf("err = %v", err)
+				}
+				if progress != 2 {
+					t.Errorf("progress = %d, want 2", progress)
+				}
+			case *ResponseError:
+				if !errors.As(err, &respErr) || *respErr != *want {
+					t.Fatalf("err = %v, want %v", err, want)
+				}
+			default:
+				if !errors.Is(err, want) {
+					t.Fatalf("err = %v, want %v", err, want)
+				}
+			}
+			if tc.wantErr != nil && progress != 0 {
+				t.Errorf("progress = %d after failed first chunk", progress)
+			}
+			if got := chunkFlags(rt); !bytes.Equal(got, tc.flags) {
+				t.Errorf("chunk flags = %v, want %v", got, tc.flags)
+			}
+		})
+	}
+}
+
+func TestUploadDefaultAckTimeout(t *testing.T) {
+	var gifData bytes.Buffer
+	frame := image.NewPaletted(image.Rect(0, 0, 32, 32), color.Palette{color.Black, color.White})
+	if err := gif.EncodeAll(&gifData, &gif.GIF{Image: []*image.Paletted{frame}, Delay: []int{10}}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		group uint8
+		send  func(d *Device, opts UploadOptions) error
+	}{
+		{
+			name:  "image",
+			group: GroupImage,
+			send: func(d *Device, opts UploadOptions) error {
+				return d.SendImageWithOptions(noisePNG(t), opts)
+			},
+		},
+		{
+			name:  "GIF",
+			group: GroupGIF,
+			send: func(d *Device, opts UploadOptions) error {
+				return d.SendGIFWithOptions(gifData.Bytes(), opts)
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rt := NewRecordingTransport()
+			ack, _ := proto.Reply{Group: tc.group, Status: proto.StatusNext}.MarshalBinary()
+			rt.OnWrite = func(packet []byte) {
+				time.AfterFunc(time.Millisecond, func() { rt.Notify(ack) })
+			}
+			d := newTestDevice(t, rt)
+
+			// A zero AckTimeout means the default, not an instant timeout
+			sent := 0
+			err := tc.send(d, UploadOptions{Progress: func(n int, total int) { sent = n }})
+			if err != nil {
+				t.Fatalf("upload = %v", err)
+			}
+			if sent == 0 {
+				t.Errorf("no progress reported")
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+/*
+Copyright © 2024 Neil Johnson <nj.designs@protonmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idot
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"strconv"
+	"strings"
+)
+
+// PanelSize is the width and height in pixels of a display
+type PanelSize int
+
+const (
+	Panel16 PanelSize = 16
+	Panel32 PanelSize = 32
+	Panel64 PanelSize = 64
+)
+
+// DefaultPanelSize is the size of the most common display
+const DefaultPanelSize = Panel32
+
+var ErrInvalidPanelSize = errors.New("panel size must be 16, 32 or 64")
+
+func (p PanelSize) String() string {
+	return fmt.Sprintf("%dx%d", p, p)
+}
+
+// Valid reports whether p is a size of display that exists
+func (p PanelSize) Valid() bool {
+	return p == Panel16 || p == Panel32 || p == Panel64
+}
+
+// orDefault returns p, or DefaultPanelSize if p is 0
+func (p PanelSize) orDefault() PanelSize {
+	if p == 0 {
+		return DefaultPanelSize
+	}
+	return p
+}
+
+// ParsePanelSize parses a panel size given as e.g. "32" or "32x32"
+func ParsePanelSize(s string) (PanelSize, error) {
+	w, h, found := strings.Cut(s, "x")
+	if found && w != h {
+		return 0, ErrInvalidPanelSize
+	}
+	n, err := strconv.Atoi(w)
+	if err != nil || !PanelSize(n).Valid() {
+		return 0, ErrInvalidPanelSize
+	}
+	return PanelSize(n), nil
+}
+
+// PanelSize returns the size of the display. The display can't report it,
+// so this is DeviceOptions.PanelSize, or DefaultPanelSize if that wasn't set.
+func (d *Device) PanelSize() PanelSize {
+	return PanelSize(d.panelSize.Load()).orDefault()
+}
+
+// SetPanelSize sets the size of the display
+func (d *Device) SetPanelSize(size PanelSize) error {
+	if !size.Valid() {
+		return ErrInvalidPanelSize
+	}
+	return d.exec(func(d *Device) error {
+		d.panelSize.Store(int64(size))
+		return nil
+	})
+}
+
+// checkImageSize checks imageData is an image the size of the display
+func (d *Device) checkImageSize(imageData []byte) error {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(imageData))
+	if err != nil {
+		return err
+	}
+	size := int(d.PanelSize())
+	if cfg.Width != size || cfg.Height != size {
+		return fmt.Errorf("%s is %dx%d, display is %s", format, cfg.Width, cfg.Height, d.PanelSize())
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+/*
+Copyright © 2024 Neil Johnson <nj.designs@protonmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idot
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+
+	_ "golang.org/x/image/bmp"
+	"golang.org/x/image/draw"
+)
+
+// FitMode selects how an image that isn't the same shape as the display
+// is scaled to it
+type FitMode int
+
+const (
+	FitContain FitMode = iota // scale to fit within the display, letterboxing the rest
+	FitCover   FitMode = iota // scale to fill the display, cropping the overflow
+	FitStretch FitMode = iota // scale each axis independently
+)
+
+var fitModeNames = []string{"fit", "fill", "stretch"}
+
+func (m FitMode) String() string {
+	if int(m) < len(fitModeNames) {
+		return fitModeNames[m]
+	}
+	return fmt.Sprintf("FitMode(%d)", m)
+}
+
+// ParseFitMode returns the FitMode named s, i.e. fit, fill or stretch
+func ParseFitMode(s string) (FitMode, error) {
+	for i, name := range fitModeNames {
+		if name == s {
+			return FitMode(i), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid fit mode %q", s)
+}
+
+// Filter selects how pixels are sampled when scaling
+type Filter int
+
+const (
+	FilterSmooth  Filter = iota
+	FilterNearest Filter = iota // best for pixel art
+)
+
+var filterNames = []string{"smooth", "nearest"}
+
+func (f Filter) String() string {
+	if int(f) < len(filterNames) {
+		return filterNames[f]
+	}
+	return fmt.Sprintf("Filter(%d)", f)
+}
+
+// ParseFilter returns the Filter named s, i.e. smooth or nearest
+func ParseFilter(s string) (Filter, error) {
+	for i, name := range filterNames {
+		if name == s {
+			return Filter(i), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid filter %q", s)
+}
+
+// PrepareOptions control how PrepareImage scales an image
+type PrepareOptions struct {
+	// Size is the size of the display. 0 means DefaultPanelSize.
+	Size   PanelSize
+	Fit    FitMode
+	Filter Filter
+	// Background fills any area not covered by the image
+	Background Colour
+}
+
+// PrepareImage decodes a PNG, JPEG, GIF (first frame) or BMP image, scales it
+// to the display and encodes it as the PNG expected by SendImage. PNGs that
+// are already the right size are returned unchanged.
+func PrepareImage(imageData []byte, opts PrepareOptions) ([]byte, error) {
+	size := int(opts.Size.orDefault())
+
+	img, format, err := image.Decode(bytes.NewReader(imageData))
+	if err != nil {
+		return nil, err
+	}
+	if format == "png" && img.Bounds().Dx() == size && img.Bounds().Dy() == size {
+		return imageData, nil
+	}
+
+	buf := new(bytes.Buffer)
+	if err := png.Encode(buf, ScaleImage(img, opts)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// PrepareImage prepares an image for this display. opts.Size defaults to
+// the display's PanelSize.
+func (d *Device) PrepareImage(imageData []byte, opts PrepareOptions) ([]byte, error) {
+	if opts.Size == 0 {
+		opts.Size = d.PanelSize()
+	}
+	return PrepareImage(imageData, opts)
+}
+
+// ScaleImage scales src to a square image the size of the display
+func ScaleImage(src image.Image, opts PrepareOptions) *image.RGBA {
+	size := int(opts.Size.orDefault())
+
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	bg := color.RGBA{opts.Background.R, opts.Background.G, opts.Background.B, 255}
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(bg), image.Point{}, draw.Src)
+
+	sr := src.Bounds()
+	dr := dst.Bounds()
+	w, h := sr.Dx(), sr.Dy()
+	if w == 0 || h == 0 {
+		return dst
+	}
+
+	switch opts.Fit {
+	case FitContain:
+		// Shrink the longer side's destination to keep the aspect ratio
+		if w > h {
+			dh := max(size*h/w, 1)
+			dr = image.Rect(0, (size-dh)/2, size, (size-dh)/2+dh)
+		} else if h > w {
+			dw := max(size*w/h, 1)
+			dr = image.Rect((size-dw)/2, 0, (size-dw)/2+dw, size)
+		}
+	case FitCover:
+		// Crop the centre square of the source
+		if w > h {
+			sr = image.Rect(sr.Min.X+(w-h)/2, sr.Min.Y, sr.Min.X+(w-h)/2+h, sr.Max.Y)
+		} else if h > w {
+			sr = image.Rect(sr.Min.X, sr.Min.Y+(h-w)/2, sr.Max.X, sr.Min.Y+(h-w)/2+w)
+		}
+	}
+
+	var scaler draw.Scaler = draw.CatmullRom
+	if opts.Filter == FilterNearest {
+		scaler = draw.NearestNeighbor
+	}
+	scaler.Scale(dst, dr, src, sr, draw.Over, nil)
+
+	return dst
+}
@@ -0,0 +1,310 @@
+/*
+Copyright © 2024 Neil Johnson <nj.designs@protonmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package proto
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// DrawMode selects what the display shows. 1 is needed before images and
+// pixels are sent.
+type DrawMode struct {
+	Mode uint8
+}
+
+func (c DrawMode) MarshalBinary() ([]byte, error) {
+	return append(header(5, 4, 1), c.Mode), nil
+}
+
+func (c *DrawMode) UnmarshalBinary(packet []byte) error {
+	if err := checkHeader(packet, 5, 4, 1); err != nil {
+		return err
+	}
+	c.Mode = packet[4]
+	return nil
+}
+
+// Brightness sets the brightness in percent
+type Brightness struct {
+	Percent uint8
+}
+
+func (c Brightness) MarshalBinary() ([]byte, error) {
+	return append(header(5, 4, 128), c.Percent), nil
+}
+
+func (c *Brightness) UnmarshalBinary(packet []byte) error {
+	if err := checkHeader(packet, 5, 4, 128); err != nil {
+		return err
+	}
+	c.Percent = packet[4]
+	return nil
+}
+
+// Screen turns the display on or off
+type Screen struct {
+	On bool
+}
+
+func (c Screen) MarshalBinary() ([]byte, error) {
+	return append(header(5, 7, 1), boolByte(c.On)), nil
+}
+
+func (c *Screen) UnmarshalBinary(packet []byte) error {
+	if err := checkHeader(packet, 5, 7, 1); err != nil {
+		return err
+	}
+	on, err := parseBool(packet[4], "screen")
+	if err != nil {
+		return err
+	}
+	c.On = on
+	return nil
+}
+
+// Freeze toggles freezing the display on its current frame
+type Freeze struct{}
+
+func (c Freeze) MarshalBinary() ([]byte, error) {
+	return header(4, 3, 0), nil
+}
+
+func (c *Freeze) UnmarshalBinary(packet []byte) error {
+	return checkHeader(packet, 4, 3, 0)
+}
+
+// Clock switches the display to show the clock
+type Clock struct {
+	Style    uint8 // 0-63
+	ShowDate bool
+	Hour24   bool
+	Colour   RGB
+}
+
+const (
+	clockShowDate = 128
+	clockHour24   = 64
+)
+
+func (c Clock) MarshalBinary() ([]byte, error) {
+	if c.Style >= clockHour24 {
+		return nil, fmt.Errorf("clock style %d out of range", c.Style)
+	}
+	sb := c.Style
+	if c.ShowDate {
+		sb |= clockShowDate
+	}
+	if c.Hour24 {
+		sb |= clockHour24
+	}
+	return append(header(8, 6, 1), sb, c.Colour.R, c.Colour.G, c.Colour.B), nil
+}
+
+func (c *Clock) UnmarshalBinary(packet []byte) error {
+	if err := checkHeader(packet, 8, 6, 1); err != nil {
+		return err
+	}
+	sb := packet[4]
+	c.Style = sb &^ (clockShowDate | clockHour24)
+	c.ShowDate = sb&clockShowDate != 0
+	c.Hour24 = sb&clockHour24 != 0
+	c.Colour = RGB{packet[5], packet[6], packet[7]}
+	return nil
+}
+
+// Time sets the display's clock
+type Time struct {
+	Year    uint8 // last two digits
+	Month   uint8
+	Day     uint8
+	Weekday uint8 // 1 is Sunday
+	Hour    uint8
+	Minute  uint8
+	Second  uint8
+}
+
+func (c Time) MarshalBinary() ([]byte, error) {
+	return append(header(11, 1, 128), c.Year, c.Month, c.Day, c.Weekday, c.Hour, c.Minute, c.Second), nil
+}
+
+func (c *Time) UnmarshalBinary(packet []byte) error {
+	if err := checkHeader(packet, 11, 1, 128); err != nil {
+		return err
+	}
+	f := packet[4:]
+	*c = Time{Year: f[0], Month: f[1], Day: f[2], Weekday: f[3], Hour: f[4], Minute: f[5], Second: f[6]}
+	return nil
+}
+
+// Countdown controls the countdown timer
+type Countdown struct {
+	Action  uint8 // 0 stop, 1 start, 2 pause, 3 restart
+	Minutes uint8
+	Seconds uint8
+}
+
+func (c Countdown) MarshalBinary() ([]byte, error) {
+	return append(header(7, 8, 128), c.Action, c.Minutes, c.Seconds), nil
+}
+
+func (c *Countdown) UnmarshalBinary(packet []byte) error {
+	if err := checkHeader(packet, 7, 8, 128); err != nil {
+		return err
+	}
+	*c = Countdown{Action: packet[4], Minutes: packet[5], Seconds: packet[6]}
+	return nil
+}
+
+// Chronograph controls the stopwatch
+type Chronograph struct {
+	Action uint8 // 0 reset, 1 start, 2 pause, 3 continue
+}
+
+func (c Chronograph) MarshalBinary() ([]byte, error) {
+	return append(header(5, 9, 128), c.Action), nil
+}
+
+func (c *Chronograph) UnmarshalBinary(packet []byte) error {
+	if err := checkHeader(packet, 5, 9, 128); err != nil {
+		return err
+	}
+	c.Action = packet[4]
+	return nil
+}
+
+// Scoreboard shows two scores
+type Scoreboard struct {
+	Left  uint16
+	Right uint16
+}
+
+func (c Scoreboard) MarshalBinary() ([]byte, error) {
+	packet := binary.LittleEndian.AppendUint16(header(8, 10, 128), c.Left)
+	return binary.LittleEndian.AppendUint16(packet, c.Right), nil
+}
+
+func (c *Scoreboard) UnmarshalBinary(packet []byte) error {
+	if err := checkHeader(packet, 8, 10, 128); err != nil {
+		return err
+	}
+	c.Left = binary.LittleEndian.Uint16(packet[4:])
+	c.Right = binary.LittleEndian.Uint16(packet[6:])
+	return nil
+}
+
+// Fill sets the whole display to one colour
+type Fill struct {
+	Colour RGB
+}
+
+func (c Fill) MarshalBinary() ([]byte, error) {
+	return append(header(7, 2, 2), c.Colour.R, c.Colour.G, c.Colour.B), nil
+}
+
+func (c *Fill) UnmarshalBinary(packet []byte) error {
+	if err := checkHeader(packet, 7, 2, 2); err != nil {
+		return err
+	}
+	c.Colour = RGB{packet[4], packet[5], packet[6]}
+	return nil
+}
+
+// EffectSpeed is the Effect.Speed always sent by the app
+const EffectSpeed = 90
+
+// Effect shows one of the built in animations
+type Effect struct {
+	Style   uint8
+	Speed   uint8
+	Colours []RGB
+}
+
+func (c Effect) MarshalBinary() ([]byte, error) {
+	n := 7 + 3*len(c.Colours)
+	if n > 0xff {
+		return nil, fmt.Errorf("%w: %d effect colours", ErrPacketTooLong, len(c.Colours))
+	}
+	packet := append(header(n, 3, 2), c.Style, c.Speed, uint8(len(c.Colours)))
+	for _, rgb := range c.Colours {
+		packet = append(packet, rgb.R, rgb.G, rgb.B)
+	}
+	return packet, nil
+}
+
+func (c *Effect) UnmarshalBinary(packet []byte) error {
+	if len(packet) < 7 {
+		return fmt.Errorf("%w: %d bytes", ErrInvalidPacket, len(packet))
+	}
+	n := 7 + 3*int(packet[6])
+	if n > 0xff {
+		return fmt.Errorf("%w: %d effect colours", ErrPacketTooLong, packet[6])
+	}
+	if err := checkHeader(packet, n, 3, 2); err != nil {
+		return err
+	}
+	c.Style = packet[4]
+	c.Speed = packet[5]
+	c.Colours = make([]RGB, packet[6])
+	for i := range c.Colours {
+		p := packet[7+3*i:]
+		c.Colours[i] = RGB{p[0], p[1], p[2]}
+	}
+	return nil
+}
+
+// Pixel sets a single pixel in draw mode 1
+type Pixel struct {
+	Colour RGB
+	X, Y   uint8
+}
+
+func (c Pixel) MarshalBinary() ([]byte, error) {
+	return append(header(10, 5, 1), 0, c.Colour.R, c.Colour.G, c.Colour.B, c.X, c.Y), nil
+}
+
+func (c *Pixel) UnmarshalBinary(packet []byte) error {
+	if err := checkHeader(packet, 10, 5, 1); err != nil {
+		return err
+	}
+	if packet[4] != 0 {
+		return fmt.Errorf("%w: pixel byte 4 is %d", ErrInvalidPacket, packet[4])
+	}
+	c.Colour = RGB{packet[5], packet[6], packet[7]}
+	c.X, c.Y = packet[8], packet[9]
+	return nil
+}
+
+func boolByte(b bool) uint8 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// parseBool decodes a flag encoded by boolByte
+func parseBool(b uint8, name string) (bool, error) {
+	if b > 1 {
+		return false, fmt.Errorf("%w: %s flag %d", ErrInvalidPacket, name, b)
+	}
+	return b == 1, nil
+}
@@ -0,0 +1,123 @@
+/*
+Copyright © 2024 Neil Johnson <nj.designs@protonmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+/*
+Package proto encodes and decodes the packets exchanged with iDot displays, without doing any I/O
+*/
+package proto
+
+import (
+	"encoding"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+var ErrUnknownCommand = errors.New("unknown command")
+
+var ErrInvalidPacket = errors.New("invalid packet")
+
+var ErrPacketTooLong = errors.New("packet too long")
+
+// RGB is a colour as sent to the display
+type RGB struct {
+	R, G, B uint8
+}
+
+// Command is a packet sent to the display
+type Command interface {
+	encoding.BinaryMarshaler
+	encoding.BinaryUnmarshaler
+}
+
+// Parse identifies and decodes a packet sent to the display. It returns a
+// pointer to one of the command types in this package. Only packets in the
+// form MarshalBinary produces are accepted, so re-encoding the command gives
+// back the same bytes.
+func Parse(packet []byte) (Command, error) {
+	if len(packet) < 4 {
+		return nil, fmt.Errorf("%w: %d bytes", ErrInvalidPacket, len(packet))
+	}
+	cmd := identify(packet)
+	if cmd == nil {
+		return nil, fmt.Errorf("%w: % x", ErrUnknownCommand, packet[:4])
+	}
+	if err := cmd.UnmarshalBinary(packet); err != nil {
+		return nil, err
+	}
+	return cmd, nil
+}
+
+// identify returns the command type packet holds, or nil
+func identify(packet []byte) Command {
+	group, sub := packet[2], packet[3]
+	switch {
+	case group == 4 && sub == 1:
+		return new(DrawMode)
+	case group == 4 && sub == 128:
+		return new(Brightness)
+	case group == 7 && sub == 1:
+		return new(Screen)
+	case group == 3 && sub == 0 && len(packet) == 4:
+		return new(Freeze)
+	case group == 6 && sub == 1:
+		return new(Clock)
+	case group == 1 && sub == 128:
+		return new(Time)
+	case group == 8 && sub == 128:
+		return new(Countdown)
+	case group == 9 && sub == 128:
+		return new(Chronograph)
+	case group == 10 && sub == 128:
+		return new(Scoreboard)
+	case group == 2 && sub == 2:
+		return new(Fill)
+	case group == 3 && sub == 2:
+		return new(Effect)
+	case group == 5 && sub == 1:
+		return new(Pixel)
+	case group == 0 && sub == 0 && len(packet) >= 9:
+		return new(ImageChunk)
+	case group == GroupGIF && sub == 0:
+		return new(GIFChunk)
+	case group == GroupText && sub == 0:
+		return new(Text)
+	}
+	return nil
+}
+
+// header returns the 4 byte header of a command packet of length n
+func header(n int, group uint8, sub uint8) []byte {
+	return []byte{uint8(n), uint8(n >> 8), group, sub}
+}
+
+// checkHeader checks packet is exactly n bytes long, with a matching
+// length prefix, group and sub command
+func checkHeader(packet []byte, n int, group uint8, sub uint8) error {
+	if len(packet) != n {
+		return fmt.Errorf("%w: %d bytes, want %d", ErrInvalidPacket, len(packet), n)
+	}
+	if int(binary.LittleEndian.Uint16(packet)) != n || packet[2] != group || packet[3] != sub {
+		return fmt.Errorf("%w: header % x", ErrInvalidPacket, packet[:4])
+	}
+	return nil
+}
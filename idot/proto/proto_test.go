@@ -0,0 +1,228 @@
+/*
+Copyright © 2024 Neil Johnson <nj.designs@protonmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package proto
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+var commandTests = []struct {
+	name   string
+	cmd    Command
+	packet []byte
+}{
+	{name: "DrawMode", cmd: &DrawMode{Mode: 1}, packet: []byte{5, 0, 4, 1, 1}},
+	{name: "Brightness", cmd: &Brightness{Percent: 50}, packet: []byte{5, 0, 4, 128, 50}},
+	{name: "Screen on", cmd: &Screen{On: true}, packet: []byte{5, 0, 7, 1, 1}},
+	{name: "Screen off", cmd: &Screen{On: false}, packet: []byte{5, 0, 7, 1, 0}},
+	{name: "Freeze", cmd: &Freeze{}, packet: []byte{4, 0, 3, 0}},
+	{
+		name:   "Clock",
+		cmd:    &Clock{Style: 4, ShowDate: true, Hour24: true, Colour: RGB{1, 2, 3}},
+		packet: []byte{8, 0, 6, 1, 196, 1, 2, 3},
+	},
+	{
+		name:   "Time",
+		cmd:    &Time{Year: 24, Month: 3, Day: 15, Weekday: 6, Hour: 13, Minute: 45, Second: 30},
+		packet: []byte{11, 0, 1, 128, 24, 3, 15, 6, 13, 45, 30},
+	},
+	{name: "Countdown", cmd: &Countdown{Action: 1, Minutes: 15, Seconds: 30}, packet: []byte{7, 0, 8, 128, 1, 15, 30}},
+	{name: "Chronograph", cmd: &Chronograph{Action: 3}, packet: []byte{5, 0, 9, 128, 3}},
+	{name: "Scoreboard", cmd: &Scoreboard{Left: 3, Right: 999}, packet: []byte{8, 0, 10, 128, 3, 0, 0xe7, 0x03}},
+	{name: "Fill", cmd: &Fill{Colour: RGB{255, 128, 0}}, packet: []byte{7, 0, 2, 2, 255, 128, 0}},
+	{
+		name:   "Effect",
+		cmd:    &Effect{Style: 2, Speed: EffectSpeed, Colours: []RGB{{255, 0, 0}, {0, 0, 255}}},
+		packet: []byte{13, 0, 3, 2, 2, 90, 2, 255, 0, 0, 0, 0, 255},
+	},
+	{name: "Pixel", cmd: &Pixel{Colour: RGB{1, 2, 3}, X: 4, Y: 5}, packet: []byte{10, 0, 5, 1, 0, 1, 2, 3, 4, 5}},
+	{
+		name:   "ImageChunk",
+		cmd:    &ImageChunk{Length: 4, Continuation: false, Total: 3, Data: []byte{7, 8, 9}},
+		packet: []byte{4, 0, 0, 0, 0, 3, 0, 0, 0, 7, 8, 9},
+	},
+	{
+		name:   "ImageChunk continuation",
+		cmd:    &ImageChunk{Length: 0x1002, Continuation: true, Total: 0x1000, Data: []byte{1}},
+		packet: []byte{2, 16, 0, 0, 2, 0, 16, 0, 0, 1},
+	},
+	{
+		name: "GIFChunk",
+		cmd:  &GIFChunk{Continuation: true, Total: 3, CRC: 0x04030201, Data: []byte{7, 8, 9}},
+		packet: []byte{
+			19, 0, 1, 0, 2,
+			3, 0, 0, 0,
+			1, 2, 3, 4,
+			5, 0, 13,
+			7, 8, 9,
+		},
+	},
+	{
+		name: "Text",
+		cmd: &Text{
+			Mode: 1, Speed: 95, ColourMode: 1, Colour: RGB{1, 2, 3},
+			BackgroundOn: true, Background: RGB{4, 5, 6},
+			Glyphs: [][]byte{{0xaa, 0x55}},
+		},
+		packet: []byte{
+			36, 0, 3, 0, 0,
+			20, 0, 0, 0,
+			0x34, 0xb9, 0xeb, 0x9d,
+			0, 0, 12,
+			1, 0, 0, 1, 1, 95, 1, 1, 2, 3, 1, 4, 5, 6,
+			2, 255, 255, 255, 0xaa, 0x55,
+		},
+	},
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, tc := range commandTests {
+		t.Run(tc.name, func(t *testing.T) {
+			packet, err := tc.cmd.MarshalBinary()
+			if err != nil {
+				t.Fatalf("MarshalBinary() = %v", err)
+			}
+			if !bytes.Equal(packet, tc.packet) {
+				t.Errorf("MarshalBinary() = % x, want % x", packet, tc.packet)
+			}
+
+			cmd, err := Parse(tc.packet)
+			if err != nil {
+				t.Fatalf("Parse() = %v", err)
+			}
+			if !reflect.DeepEqual(cmd, tc.cmd) {
+				t.Errorf("Parse() = %+v, want %+v", cmd, tc.cmd)
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		packet []byte
+		want   error
+	}{
+		{name: "short", packet: []byte{5, 0, 4}, want: ErrInvalidPacket},
+		{name: "unknown", packet: []byte{5, 0, 99, 1, 1}, want: ErrUnknownCommand},
+		{name: "length", packet: []byte{6, 0, 4, 1, 1}, want: ErrInvalidPacket},
+		{name: "truncated", packet: []byte{8, 0, 6, 1, 196, 1, 2}, want: ErrInvalidPacket},
+		{name: "screen flag", packet: []byte{5, 0, 7, 1, 0x30}, want: ErrInvalidPacket},
+		{name: "pixel byte 4", packet: []byte{10, 0, 5, 1, 9, 1, 2, 3, 4, 5}, want: ErrInvalidPacket},
+		{name: "chunk flag", packet: []byte{4, 0, 0, 0, 1, 3, 0, 0, 0, 7, 8, 9}, want: ErrInvalidPacket},
+		{name: "effect colours", packet: []byte{13, 0, 3, 2, 2, 90, 3, 255, 0, 0, 0, 0, 255}, want: ErrInvalidPacket},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := Parse(tc.packet); !errors.Is(err, tc.want) {
+				t.Errorf("Parse(% x) = %v, want %v", tc.packet, err, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseInvalidText(t *testing.T) {
+	var text []byte
+	for _, tc := range commandTests {
+		if tc.name == "Text" {
+			text = tc.packet
+		}
+	}
+
+	tests := []struct {
+		name   string
+		offset int
+		value  byte
+	}{
+		{name: "byte 4", offset: 4, value: 1},
+		{name: "crc", offset: 9, value: 0},
+		{name: "payload header", offset: 19, value: 0},
+		{name: "background flag", offset: 26, value: 2},
+		{name: "glyph separator", offset: 30, value: 1},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			packet := bytes.Clone(text)
+			packet[tc.offset] = tc.value
+			if _, err := Parse(packet); !errors.Is(err, ErrInvalidPacket) {
+				t.Errorf("Parse(% x) = %v, want %v", packet, err, ErrInvalidPacket)
+			}
+		})
+	}
+}
+
+func TestMarshalTooLong(t *testing.T) {
+	tests := []struct {
+		name string
+		cmd  Command
+	}{
+		{name: "Effect", cmd: &Effect{Colours: make([]RGB, 100)}},
+		{name: "GIFChunk", cmd: &GIFChunk{Data: make([]byte, 0x10000)}},
+		{name: "Text", cmd: &Text{Glyphs: [][]byte{make([]byte, 0x10000)}}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := tc.cmd.MarshalBinary(); !errors.Is(err, ErrPacketTooLong) {
+				t.Errorf("MarshalBinary() = %v, want %v", err, ErrPacketTooLong)
+			}
+		})
+	}
+}
+
+func TestReply(t *testing.T) {
+	packet, err := Reply{Group: GroupImage, Status: StatusNext}.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []byte{5, 0, 2, 0, 1}; !bytes.Equal(packet, want) {
+		t.Errorf("MarshalBinary() = % x, want % x", packet, want)
+	}
+	var reply Reply
+	if err := reply.UnmarshalBinary(packet); err != nil {
+		t.Fatal(err)
+	}
+	if want := (Reply{Group: GroupImage, Status: StatusNext}); reply != want {
+		t.Errorf("UnmarshalBinary() = %+v, want %+v", reply, want)
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	for _, tc := range commandTests {
+		f.Add(tc.packet)
+	}
+	f.Fuzz(func(t *testing.T, packet []byte) {
+		cmd, err := Parse(packet)
+		if err != nil {
+			return
+		}
+		again, err := cmd.MarshalBinary()
+		if err != nil {
+			t.Fatalf("MarshalBinary() of parsed % x = %v", packet, err)
+		}
+		if !bytes.Equal(again, packet) {
+			t.Fatalf("% x parsed as %+v, which encodes as % x", packet, cmd, again)
+		}
+	})
+}
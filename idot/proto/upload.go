@@ -0,0 +1,309 @@
+/*
+Copyright © 2024 Neil Johnson <nj.designs@protonmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package proto
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+)
+
+// ChunkSize is the most image or GIF data sent in one packet
+const ChunkSize = 4096
+
+// Command groups the display replies to
+const (
+	GroupGIF   = 1
+	GroupImage = 2
+	GroupText  = 3
+)
+
+// chunk flags
+const (
+	firstChunk        = 0
+	continuationChunk = 2
+)
+
+// ImageChunk is one packet of a PNG upload
+type ImageChunk struct {
+	// Length isn't the packet length. As in the app, it is the size of the
+	// whole image plus the number of chunks.
+	Length       uint16
+	Continuation bool
+	Total        uint32 // size of the whole image
+	Data         []byte
+}
+
+// ImageChunks splits a PNG in to the chunks that upload it.
+// Based on create_payloads in core/idotmatrix/image.py
+func ImageChunks(png []byte) []ImageChunk {
+	data := split(png)
+	chunks := make([]ImageChunk, len(data))
+	for i, d := range data {
+		chunks[i] = ImageChunk{
+			Length:       uint16(len(png) + len(data)),
+			Continuation: i > 0,
+			Total:        uint32(len(png)),
+			Data:         d,
+		}
+	}
+	return chunks
+}
+
+func (c ImageChunk) MarshalBinary() ([]byte, error) {
+	packet := binary.LittleEndian.AppendUint16(nil, c.Length)
+	packet = append(packet, 0, 0, chunkFlag(c.Continuation))
+	packet = binary.LittleEndian.AppendUint32(packet, c.Total)
+	return append(packet, c.Data...), nil
+}
+
+func (c *ImageChunk) UnmarshalBinary(packet []byte) error {
+	if len(packet) < 9 || packet[2] != 0 || packet[3] != 0 {
+		return fmt.Errorf("%w: not an image chunk", ErrInvalidPacket)
+	}
+	continuation, err := parseChunkFlag(packet[4])
+	if err != nil {
+		return err
+	}
+	*c = ImageChunk{
+		Length:       binary.LittleEndian.Uint16(packet),
+		Continuation: continuation,
+		Total:        binary.LittleEndian.Uint32(packet[5:]),
+		Data:         append([]byte(nil), packet[9:]...),
+	}
+	return nil
+}
+
+// gifTrailer ends the header of every GIF chunk
+var gifTrailer = []byte{5, 0, 13}
+
+const gifHeaderLen = 16
+
+// GIFChunk is one packet of a GIF upload
+type GIFChunk struct {
+	Continuation bool
+	Total        uint32 // size of the whole GIF
+	CRC          uint32 // CRC-32 of the whole GIF
+	Data         []byte
+}
+
+// GIFChunks splits a GIF in to the chunks that upload it.
+// Based on _createPayloads in core/idotmatrix/gif.py
+func GIFChunks(gif []byte) []GIFChunk {
+	crc := crc32.ChecksumIEEE(gif)
+	data := split(gif)
+	chunks := make([]GIFChunk, len(data))
+	for i, d := range data {
+		chunks[i] = GIFChunk{Continuation: i > 0, Total: uint32(len(gif)), CRC: crc, Data: d}
+	}
+	return chunks
+}
+
+func (c GIFChunk) MarshalBinary() ([]byte, error) {
+	n := len(c.Data) + gifHeaderLen
+	if n > 0xffff {
+		return nil, fmt.Errorf("%w: %d byte GIF chunk", ErrPacketTooLong, len(c.Data))
+	}
+	packet := append(header(n, GroupGIF, 0), chunkFlag(c.Continuation))
+	packet = binary.LittleEndian.AppendUint32(packet, c.Total)
+	packet = binary.LittleEndian.AppendUint32(packet, c.CRC)
+	packet = append(packet, gifTrailer...)
+	return append(packet, c.Data...), nil
+}
+
+func (c *GIFChunk) UnmarshalBinary(packet []byte) error {
+	if len(packet) < gifHeaderLen || !bytes.Equal(packet[13:16], gifTrailer) {
+		return fmt.Errorf("%w: not a GIF chunk", ErrInvalidPacket)
+	}
+	if err := checkHeader(packet, len(packet), GroupGIF, 0); err != nil {
+		return err
+	}
+	continuation, err := parseChunkFlag(packet[4])
+	if err != nil {
+		return err
+	}
+	*c = GIFChunk{
+		Continuation: continuation,
+		Total:        binary.LittleEndian.Uint32(packet[5:]),
+		CRC:          binary.LittleEndian.Uint32(packet[9:]),
+		Data:         append([]byte(nil), packet[gifHeaderLen:]...),
+	}
+	return nil
+}
+
+// textTrailer ends the header of a text packet
+var textTrailer = []byte{0, 0, 12}
+
+// glyphSeparator precedes each character bitmap
+var glyphSeparator = []byte{2, 255, 255, 255}
+
+const (
+	textHeaderLen     = 16
+	textPayloadHeader = 14
+)
+
+// Text shows scrolling text. Each glyph is a bitmap of one character, 1 bit
+// per pixel, least significant bit first.
+type Text struct {
+	Mode         uint8
+	Speed        uint8
+	ColourMode   uint8
+	Colour       RGB
+	BackgroundOn bool
+	Background   RGB
+	Glyphs       [][]byte
+}
+
+// Based on _buildStringPacket in core/idotmatrix/text.py
+func (c Text) MarshalBinary() ([]byte, error) {
+	payload := binary.LittleEndian.AppendUint16(nil, uint16(len(c.Glyphs)))
+	payload = append(payload, 0, 1, c.Mode, c.Speed, c.ColourMode,
+		c.Colour.R, c.Colour.G, c.Colour.B,
+		boolByte(c.BackgroundOn), c.Background.R, c.Background.G, c.Background.B)
+	for _, g := range c.Glyphs {
+		payload = append(payload, glyphSeparator...)
+		payload = append(payload, g...)
+	}
+
+	n := len(payload) + textHeaderLen
+	if n > 0xffff {
+		return nil, fmt.Errorf("%w: %d byte text", ErrPacketTooLong, n)
+	}
+	packet := append(header(n, GroupText, 0), 0)
+	packet = binary.LittleEndian.AppendUint32(packet, uint32(len(payload)))
+	packet = binary.LittleEndian.AppendUint32(packet, crc32.ChecksumIEEE(payload))
+	packet = append(packet, textTrailer...)
+	return append(packet, payload...), nil
+}
+
+func (c *Text) UnmarshalBinary(packet []byte) error {
+	if len(packet) < textHeaderLen+textPayloadHeader || !bytes.Equal(packet[13:16], textTrailer) {
+		return fmt.Errorf("%w: not a text packet", ErrInvalidPacket)
+	}
+	if err := checkHeader(packet, len(packet), GroupText, 0); err != nil {
+		return err
+	}
+	if packet[4] != 0 {
+		return fmt.Errorf("%w: text byte 4 is %d", ErrInvalidPacket, packet[4])
+	}
+	payload := packet[textHeaderLen:]
+	if int(binary.LittleEndian.Uint32(packet[5:])) != len(payload) {
+		return fmt.Errorf("%w: text payload length", ErrInvalidPacket)
+	}
+	if crc32.ChecksumIEEE(payload) != binary.LittleEndian.Uint32(packet[9:]) {
+		return fmt.Errorf("%w: text crc mismatch", ErrInvalidPacket)
+	}
+
+	if payload[2] != 0 || payload[3] != 1 {
+		return fmt.Errorf("%w: text payload header % x", ErrInvalidPacket, payload[2:4])
+	}
+	backgroundOn, err := parseBool(payload[10], "text background")
+	if err != nil {
+		return err
+	}
+
+	*c = Text{
+		Mode:         payload[4],
+		Speed:        payload[5],
+		ColourMode:   payload[6],
+		Colour:       RGB{payload[7], payload[8], payload[9]},
+		BackgroundOn: backgroundOn,
+		Background:   RGB{payload[11], payload[12], payload[13]},
+	}
+
+	numChars := int(binary.LittleEndian.Uint16(payload))
+	glyphs := payload[textPayloadHeader:]
+	if numChars == 0 {
+		if len(glyphs) != 0 {
+			return fmt.Errorf("%w: text glyphs", ErrInvalidPacket)
+		}
+		return nil
+	}
+	if len(glyphs)%numChars != 0 || len(glyphs)/numChars <= len(glyphSeparator) {
+		return fmt.Errorf("%w: text glyphs", ErrInvalidPacket)
+	}
+	size := len(glyphs) / numChars
+	for i := 0; i < numChars; i++ {
+		g := glyphs[i*size : (i+1)*size]
+		if !bytes.Equal(g[:len(glyphSeparator)], glyphSeparator) {
+			return fmt.Errorf("%w: glyph %d separator", ErrInvalidPacket, i)
+		}
+		c.Glyphs = append(c.Glyphs, append([]byte(nil), g[len(glyphSeparator):]...))
+	}
+	return nil
+}
+
+// Reply statuses
+const (
+	StatusFailed   = 0
+	StatusNext     = 1 // chunk received, send the next
+	StatusRejected = 2
+	StatusComplete = 3
+)
+
+// Reply is a notification from the display acknowledging an upload
+type Reply struct {
+	Group  uint8
+	Status uint8
+}
+
+func (c Reply) MarshalBinary() ([]byte, error) {
+	return append(header(5, c.Group, 0), c.Status), nil
+}
+
+func (c *Reply) UnmarshalBinary(packet []byte) error {
+	if len(packet) != 5 || packet[0] != 5 || packet[1] != 0 {
+		return fmt.Errorf("%w: not a reply", ErrInvalidPacket)
+	}
+	c.Group = packet[2]
+	c.Status = packet[4]
+	return nil
+}
+
+func chunkFlag(continuation bool) uint8 {
+	if continuation {
+		return continuationChunk
+	}
+	return firstChunk
+}
+
+func parseChunkFlag(flag uint8) (bool, error) {
+	switch flag {
+	case firstChunk:
+		return false, nil
+	case continuationChunk:
+		return true, nil
+	}
+	return false, fmt.Errorf("%w: chunk flag %d", ErrInvalidPacket, flag)
+}
+
+// split breaks data in to ChunkSize pieces
+func split(data []byte) [][]byte {
+	var chunks [][]byte
+	for len(data) > 0 {
+		n := min(ChunkSize, len(data))
+		chunks = append(chunks, data[:n])
+		data = data[n:]
+	}
+	return chunks
+}
@@ -0,0 +1,192 @@
+/*
+Copyright © 2024 Neil Johnson <nj.designs@protonmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idot
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+)
+
+// Priority orders commands waiting to be sent to the display. Commands of
+// the same priority are sent in the order they were issued.
+type Priority int
+
+const (
+	PriorityLow    Priority = -1
+	PriorityNormal Priority = 0
+	PriorityHigh   Priority = 1 // used by ScreenOn, ScreenOff and Freeze
+)
+
+// job is a queued command
+type job struct {
+	ctx   context.Context
+	prio  Priority
+	seq   uint64
+	fn    func(ctx context.Context) error
+	done  chan error
+	index int // position in the heap, -1 once taken off it
+}
+
+// jobHeap is a container/heap of jobs, highest priority first
+type jobHeap []*job
+
+func (h jobHeap) Len() int {
+	return len(h)
+}
+
+func (h jobHeap) Less(i int, j int) bool {
+	if h[i].prio != h[j].prio {
+		return h[i].prio > h[j].prio
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h jobHeap) Swap(i int, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *jobHeap) Push(x any) {
+	j := x.(*job)
+	j.index = len(*h)
+	*h = append(*h, j)
+}
+
+func (h *jobHeap) Pop() any {
+	old := *h
+	j := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	j.index = -1
+	return j
+}
+
+// commandQueue runs jobs one at a time. A worker goroutine is started when
+// a job is queued and exits once the queue is empty.
+type commandQueue struct {
+	mu      sync.Mutex
+	jobs    jobHeap
+	seq     uint64
+	working bool
+}
+
+// run queues fn and waits for its result. If ctx is done before fn starts,
+// fn is dropped from the queue. If ctx is done while fn runs, ctx.Err() is
+// returned straight away and fn is left to notice the cancellation itself.
+func (q *commandQueue) run(ctx context.Context, prio Priority, fn func(ctx context.Context) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	j := &job{ctx: ctx, prio: prio, fn: fn, done: make(chan error, 1)}
+	q.mu.Lock()
+	q.seq++
+	j.seq = q.seq
+	heap.Push(&q.jobs, j)
+	if !q.working {
+		q.working = true
+		go q.work()
+	}
+	q.mu.Unlock()
+
+	select {
+	case err := <-j.done:
+		return err
+	case <-ctx.Done():
+	}
+
+	q.mu.Lock()
+	if j.index >= 0 {
+		heap.Remove(&q.jobs, j.index)
+	}
+	q.mu.Unlock()
+
+	select {
+	case err := <-j.done:
+		// Finished as ctx was cancelled
+		return err
+	default:
+		return ctx.Err()
+	}
+}
+
+func (q *commandQueue) work() {
+	for {
+		q.mu.Lock()
+		if len(q.jobs) == 0 {
+			q.working = false
+			q.mu.Unlock()
+			return
+		}
+		j := heap.Pop(&q.jobs).(*job)
+		q.mu.Unlock()
+
+		if err := j.ctx.Err(); err != nil {
+			j.done <- err
+			continue
+		}
+		j.done <- j.fn(j.ctx)
+	}
+}
+
+// WithContext returns a view of d whose commands are abandoned when ctx is
+// done, whether they are waiting in the queue or part way through an upload
+func (d *Device) WithContext(ctx context.Context) *Device {
+	v := *d
+	v.ctx = ctx
+	return &v
+}
+
+// WithPriority returns a view of d whose commands are queued with priority p
+func (d *Device) WithPriority(p Priority) *Device {
+	v := *d
+	v.prio = p
+	return &v
+}
+
+// Do runs fn as a single command, so nothing else is sent to the display
+// until it returns. fn must only use the Device passed to it; calling
+// methods on d itself from fn would wait for fn to finish.
+func (d *Device) Do(fn func(d *Device) error) error {
+	return d.exec(fn)
+}
+
+// exec runs fn with exclusive use of the display. Unless d is already
+// running as part of a command, fn waits its turn in the queue.
+func (d *Device) exec(fn func(d *Device) error) error {
+	if d.exclusive {
+		return fn(d)
+	}
+	return d.queue.run(d.context(), d.prio, func(ctx context.Context) error {
+		return fn(&Device{device: d.device, ctx: ctx, prio: d.prio, exclusive: true})
+	})
+}
+
+// context returns the context commands issued through d run under
+func (d *Device) context() context.Context {
+	if d.ctx == nil {
+		return context.Background()
+	}
+	return d.ctx
+}
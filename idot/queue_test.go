@@ -0,0 +1,311 @@
+/*
+Copyright © 2024 Neil Johnson <nj.designs@protonmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idot
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nj-designs/go-idot/idot/proto"
+)
+
+// waitQueued waits until n commands are waiting in d's queue
+func waitQueued(t *testing.T, d *Device, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		d.queue.mu.Lock()
+		queued := len(d.queue.jobs)
+		d.queue.mu.Unlock()
+		if queued == n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d commands queued, want %d", queued, n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// blockQueue runs a command on d that holds the queue until the returned
+// function is called
+func blockQueue(t *testing.T, d *Device) func() {
+	t.Helper()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	go d.Do(func(d *Device) error {
+		close(started)
+		<-release
+		return nil
+	})
+	<-started
+	return sync.OnceFunc(func() { close(release) })
+}
+
+func TestQueueOrder(t *testing.T) {
+	d := newTestDevice(t, NewRecordingTransport())
+	release := blockQueue(t, d)
+	defer release()
+
+	var mu sync.Mutex
+	var order []string
+	var wg sync.WaitGroup
+	queue := func(name string, prio Priority) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.WithPriority(prio).Do(func(d *Device) error {
+				mu.Lock()
+				defer mu.Unlock()
+				order = append(order, name)
+				return nil
+			})
+		}()
+	}
+
+	// Queue one at a time so the order they were issued in is known
+	issued := []struct {
+		name string
+		prio Priority
+	}{
+		{"low1", PriorityLow},
+		{"normal1", PriorityNormal},
+		{"high1", PriorityHigh},
+		{"normal2", PriorityNormal},
+		{"low2", PriorityLow},
+		{"high2", PriorityHigh},
+		{"normal3", PriorityNormal},
+	}
+	for i, cmd := range issued {
+		queue(cmd.name, cmd.prio)
+		waitQueued(t, d, i+1)
+	}
+	release()
+	wg.Wait()
+
+	want := []string{"high1", "high2", "normal1", "normal2", "normal3", "low1", "low2"}
+	if !slices.Equal(order, want) {
+		t.Errorf("ran %v, want %v", order, want)
+	}
+}
+
+func TestQueueResults(t *testing.T) {
+	d := newTestDevice(t, NewRecordingTransport())
+
+	errs := make([]error, 8)
+	var wg sync.WaitGroup
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = d.Do(func(d *Device) error {
+				return fmt.Errorf("command %d", i)
+			})
+		}()
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if want := fmt.Sprintf("command %d", i); err == nil || err.Error() != want {
+			t.Errorf("command %d returned %v, want %s", i, err, want)
+		}
+	}
+}
+
+func TestScreenOffJumpsQueue(t *testing.T) {
+	img, err := os.ReadFile("../testdata/demo_32.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rt := NewRecordingTransport()
+	ack, _ := proto.Reply{Group: GroupImage, Status: proto.StatusNext}.MarshalBinary()
+	acks := make(chan struct{})
+	rt.OnWrite = func(packet []byte) {
+		if len(packet) > 5 {
+			go func() {
+				<-acks
+				rt.Notify(ack)
+			}()
+		}
+	}
+	d := newTestDevice(t, rt)
+
+	// The first upload is waiting for its ack, the second is queued behind it
+	uploads := make(chan error, 2)
+	go func() {
+		uploads <- d.SendImage(img)
+	}()
+	for len(rt.Packets()) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	go func() {
+		uploads <- d.SendImage(img)
+	}()
+	waitQueued(t, d, 1)
+	screenOff := make(chan error, 1)
+	go func() {
+		screenOff <- d.ScreenOff()
+	}()
+	waitQueued(t, d, 2)
+
+	close(acks)
+	for i := 0; i < 2; i++ {
+		if err := <-uploads; err != nil {
+			t.Fatalf("SendImage() = %v", err)
+		}
+	}
+	if err := <-screenOff; err != nil {
+		t.Fatalf("ScreenOff() = %v", err)
+	}
+
+	var sizes []int
+	for _, p := range rt.Packets() {
+		sizes = append(sizes, len(p))
+	}
+	upload := len(img) + 9
+	if want := []int{upload, 5, upload}; !slices.Equal(sizes, want) {
+		t.Errorf("packet sizes = %v, want %v", sizes, want)
+	}
+}
+
+func TestQueueCancel(t *testing.T) {
+	rt := NewRecordingTransport()
+	d := newTestDevice(t, rt)
+	release := blockQueue(t, d)
+	defer release()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error, 1)
+	go func() {
+		result <- d.WithContext(ctx).SetDrawMode(1)
+	}()
+	waitQueued(t, d, 1)
+	cancel()
+
+	select {
+	case err := <-result:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("SetDrawMode() = %v, want %v", err, context.Canceled)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("cancelled command still waiting")
+	}
+	waitQueued(t, d, 0)
+
+	release()
+	if err := d.SetBrightness(50); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := rt.Bytes(), []byte{5, 0, 4, 128, 50}; !bytes.Equal(got, want) {
+		t.Errorf("sent % x, want % x", got, want)
+	}
+}
+
+func TestConcurrentCommandsDontInterleave(t *testing.T) {
+	var images [][]byte
+	for _, name := range []string{"demo_32.png", "doll_32.png"} {
+		img, err := os.ReadFile("../testdata/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		images = append(images, img)
+	}
+
+	rt := &RecordingTransport{MaxPacketSize: 20}
+	ackEveryWrite(rt, GroupImage, proto.StatusNext)
+	d := newTestDevice(t, rt)
+
+	var commands [][]byte
+	for _, img := range images {
+		packet, _ := proto.ImageChunks(img)[0].MarshalBinary()
+		commands = append(commands, packet)
+	}
+	commands = append(commands, []byte{5, 0, 4, 128, 50})
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 30)
+	for i := 0; i < 10; i++ {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			errs <- d.SendImage(images[0])
+		}()
+		go func() {
+			defer wg.Done()
+			errs <- d.SendImage(images[1])
+		}()
+		go func() {
+			defer wg.Done()
+			errs <- d.SetBrightness(50)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The stream must be made up of whole commands, one after another
+	stream := rt.Bytes()
+	for n := 0; len(stream) > 0; n++ {
+		i := slices.IndexFunc(commands, func(cmd []byte) bool {
+			return bytes.HasPrefix(stream, cmd)
+		})
+		if i < 0 {
+			t.Fatalf("command %d is interleaved: % x", n, stream[:min(len(stream), 32)])
+		}
+		stream = stream[len(commands[i]):]
+	}
+}
+
+func TestPanelSizeConcurrentUse(t *testing.T) {
+	d := newTestDevice(t, NewRecordingTransport())
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			d.SetPanelSize(Panel16)
+			d.SetPanelSize(Panel64)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			if size := d.PanelSize(); !size.Valid() {
+				t.Errorf("PanelSize() = %d", size)
+			}
+		}
+	}()
+	wg.Wait()
+}
@@ -0,0 +1,61 @@
+/*
+Copyright © 2024 Neil Johnson <nj.designs@protonmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idot
+
+import (
+	"errors"
+
+	"github.com/nj-designs/go-idot/idot/proto"
+)
+
+const (
+	MinBrightness = 5
+	MaxBrightness = 100
+)
+
+var ErrInvalidBrightness = errors.New("brightness must be between 5 and 100 percent")
+
+// SetBrightness sets the brightness of the display as a percentage
+func (d *Device) SetBrightness(percent int) error {
+	if percent < MinBrightness || percent > MaxBrightness {
+		return ErrInvalidBrightness
+	}
+	return d.send(proto.Brightness{Percent: uint8(percent)})
+}
+
+// ScreenOn turns the display on. It jumps ahead of queued commands of
+// lower than PriorityHigh.
+func (d *Device) ScreenOn() error {
+	return d.WithPriority(max(d.prio, PriorityHigh)).send(proto.Screen{On: true})
+}
+
+// ScreenOff blanks the display. It jumps ahead of queued commands of
+// lower than PriorityHigh.
+func (d *Device) ScreenOff() error {
+	return d.WithPriority(max(d.prio, PriorityHigh)).send(proto.Screen{On: false})
+}
+
+// Freeze toggles freezing the display on its current frame. It jumps ahead
+// of queued commands of lower than PriorityHigh.
+func (d *Device) Freeze() error {
+	return d.WithPriority(max(d.prio, PriorityHigh)).send(proto.Freeze{})
+}
@@ -0,0 +1,55 @@
+/*
+Copyright © 2024 Neil Johnson <nj.designs@protonmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idot
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestSetBrightness(t *testing.T) {
+	for _, percent := range []int{MinBrightness, 50, MaxBrightness} {
+		rt := NewRecordingTransport()
+		d := newTestDevice(t, rt)
+		if err := d.SetBrightness(percent); err != nil {
+			t.Fatalf("SetBrightness(%d) = %v", percent, err)
+		}
+		want := []byte{5, 0, 4, 128, byte(percent)}
+		if got := rt.Bytes(); !bytes.Equal(got, want) {
+			t.Errorf("SetBrightness(%d) sent % x, want % x", percent, got, want)
+		}
+	}
+}
+
+func TestSetBrightnessInvalid(t *testing.T) {
+	for _, percent := range []int{MinBrightness - 1, MaxBrightness + 1} {
+		rt := NewRecordingTransport()
+		d := newTestDevice(t, rt)
+		if err := d.SetBrightness(percent); !errors.Is(err, ErrInvalidBrightness) {
+			t.Errorf("SetBrightness(%d) = %v, want %v", percent, err, ErrInvalidBrightness)
+		}
+		if got := rt.Bytes(); len(got) != 0 {
+			t.Errorf("SetBrightness(%d) sent % x", percent, got)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+/*
+Copyright © 2024 Neil Johnson <nj.designs@protonmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idot
+
+import (
+	"time"
+)
+
+// ConnState is the state of the link to the display
+type ConnState int
+
+const (
+	StateDisconnected ConnState = iota
+	StateConnecting   ConnState = iota
+	StateConnected    ConnState = iota
+	StateReconnecting ConnState = iota
+)
+
+func (s ConnState) String() string {
+	switch s {
+	case StateConnecting:
+		return "connecting"
+	case StateConnected:
+		return "connected"
+	case StateReconnecting:
+		return "reconnecting"
+	default:
+		return "disconnected"
+	}
+}
+
+const (
+	defaultReconnectMinBackoff = time.Second
+	defaultReconnectMaxBackoff = time.Minute
+)
+
+// DisconnectNotifier is implemented by transports that can report the link
+// to the display dropping. Supervised devices use it to trigger a reconnect.
+type DisconnectNotifier interface {
+	// Disconnected returns a channel that is closed when the link drops
+	Disconnected() <-chan struct{}
+}
+
+// State returns the current state of the link
+func (d *Device) State() ConnState {
+	d.connMu.Lock()
+	defer d.connMu.Unlock()
+
+	return d.state
+}
+
+// setState records s and publishes it as an EventStateChange
+func (d *Device) setState(s ConnState) {
+	d.connMu.Lock()
+	changed := d.state != s
+	d.state = s
+	d.connMu.Unlock()
+
+	if changed {
+		d.publish(Event{Kind: EventStateChange, State: s})
+	}
+}
+
+// wakeLocked releases writers waiting for a reconnect. connMu must be held.
+func (d *Device) wakeLocked() {
+	if d.ready != nil {
+		close(d.ready)
+		d.ready = nil
+	}
+}
+
+// linkDown tells the supervisor, if any, that writes to t are failing
+func (d *Device) linkDown(t Transport) {
+	if d.linkLost == nil {
+		return
+	}
+	d.markDown(t)
+	select {
+	case d.linkLost <- t:
+	default:
+	}
+}
+
+// markDown stops writes going to t and puts the device in the reconnecting
+// state, if t is still the transport in use
+func (d *Device) markDown(t Transport) {
+	d.connMu.Lock()
+	if d.transport != t {
+		d.connMu.Unlock()
+		return
+	}
+	// Set the state with the transport, so a writer never sees no transport
+	// without a reconnect to wait for
+	d.transport = nil
+	d.ready = make(chan struct{})
+	changed := d.state != StateReconnecting
+	d.state = StateReconnecting
+	d.connMu.Unlock()
+
+	if changed {
+		d.publish(Event{Kind: EventStateChange, State: StateReconnecting})
+	}
+}
+
+// supervise waits for the link over t to drop then reconnects, until
+// Disconnect is called
+func (d *Device) supervise(t Transport) {
+	defer d.supervisor.Done()
+
+	for {
+		select {
+		case <-d.stop:
+			return
+		case <-disconnected(t):
+		case lost := <-d.linkLost:
+			if lost != t {
+				continue
+			}
+		}
+
+		d.markDown(t)
+		t.Close()
+
+		if t = d.reconnect(); t == nil {
+			return
+		}
+	}
+}
+
+// reconnect dials the display with exponential backoff until it succeeds
+// or Disconnect is called, in which case nil is returned
+func (d *Device) reconnect() Transport {
+	backoff := d.opts.ReconnectMinBackoff
+	if backoff <= 0 {
+		backoff = defaultReconnectMinBackoff
+	}
+	maxBackoff := d.opts.ReconnectMaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = defaultReconnectMaxBackoff
+	}
+
+	for {
+		timer := time.NewTimer(backoff)
+		select {
+		case <-d.stop:
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+
+		t, err := d.open()
+		if err == nil {
+			d.attach(t)
+			return t
+		}
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+// disconnected returns the Disconnected channel of t, or nil if t can't
+// report the link dropping
+func disconnected(t Transport) <-chan struct{} {
+	if dn, ok := t.(DisconnectNotifier); ok {
+		return dn.Disconnected()
+	}
+	return nil
+}
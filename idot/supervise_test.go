@@ -0,0 +1,209 @@
+/*
+Copyright © 2024 Neil Johnson <nj.designs@protonmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idot
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testDialer hands out a new RecordingTransport each time it is dialled
+type testDialer struct {
+	mu         sync.Mutex
+	transports []*RecordingTransport
+	fail       bool
+}
+
+func (td *testDialer) dial() (Transport, error) {
+	td.mu.Lock()
+	defer td.mu.Unlock()
+
+	if td.fail {
+		return nil, errors.New("dial failed")
+	}
+	rt := NewRecordingTransport()
+	td.transports = append(td.transports, rt)
+	return rt, nil
+}
+
+func (td *testDialer) setFail(fail bool) {
+	td.mu.Lock()
+	defer td.mu.Unlock()
+
+	td.fail = fail
+}
+
+// transport returns the i'th transport dialled
+func (td *testDialer) transport(i int) *RecordingTransport {
+	td.mu.Lock()
+	defer td.mu.Unlock()
+
+	if i >= len(td.transports) {
+		return nil
+	}
+	return td.transports[i]
+}
+
+func newSupervisedDevice(t *testing.T, td *testDialer, reconnectWait time.Duration) *Device {
+	t.Helper()
+	d := NewDeviceWithDialer(td.dial)
+	err := d.ConnectWithOptions(ConnectOptions{
+		Supervise:           true,
+		ReconnectMinBackoff: 5 * time.Millisecond,
+		ReconnectMaxBackoff: 5 * time.Millisecond,
+		ReconnectWait:       reconnectWait,
+	})
+	if err != nil {
+		t.Fatalf("ConnectWithOptions() = %v", err)
+	}
+	t.Cleanup(func() { d.Disconnect() })
+	return d
+}
+
+// waitForState waits for an EventStateChange to state on events
+func waitForState(t *testing.T, events <-chan Event, state ConnState) {
+	t.Helper()
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case ev := <-events:
+			if ev.Kind == EventStateChange && ev.State == state {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for state %s", state)
+		}
+	}
+}
+
+func TestSupervisedReconnect(t *testing.T) {
+	td := &testDialer{}
+	d := newSupervisedDevice(t, td, 0)
+	events := make(chan Event, 16)
+	d.Notify(events)
+
+	td.transport(0).Drop()
+	waitForState(t, events, StateReconnecting)
+	waitForState(t, events, StateConnected)
+
+	if err := d.SetDrawMode(1); err != nil {
+		t.Fatalf("SetDrawMode() after reconnect = %v", err)
+	}
+	if got, want := td.transport(1).Bytes(), []byte{5, 0, 4, 1, 1}; !bytes.Equal(got, want) {
+		t.Errorf("sent % x after reconnect, want % x", got, want)
+	}
+}
+
+func TestWriteWaitsForReconnect(t *testing.T) {
+	td := &testDialer{}
+	d := newSupervisedDevice(t, td, time.Second)
+
+	// Write straight after the drop, before the supervisor can notice it
+	td.transport(0).Drop()
+	if err := d.SetDrawMode(1); err != nil {
+		t.Fatalf("SetDrawMode() = %v", err)
+	}
+	if got := td.transport(0).Bytes(); len(got) != 0 {
+		t.Errorf("sent % x to dropped transport", got)
+	}
+	if got, want := td.transport(1).Bytes(), []byte{5, 0, 4, 1, 1}; !bytes.Equal(got, want) {
+		t.Errorf("sent % x after reconnect, want % x", got, want)
+	}
+}
+
+func TestWriteWhileReconnecting(t *testing.T) {
+	td := &testDialer{}
+	d := newSupervisedDevice(t, td, 0)
+
+	td.setFail(true)
+	td.transport(0).Drop()
+	if err := d.SetDrawMode(1); !errors.Is(err, ErrTransportClosed) && !errors.Is(err, ErrReconnecting) {
+		t.Fatalf("SetDrawMode() = %v", err)
+	}
+	// A failed write puts the device straight in to the reconnecting state
+	if s := d.State(); s != StateReconnecting {
+		t.Errorf("State() = %s, want %s", s, StateReconnecting)
+	}
+	if err := d.SetDrawMode(1); !errors.Is(err, ErrReconnecting) {
+		t.Errorf("SetDrawMode() while reconnecting = %v, want %v", err, ErrReconnecting)
+	}
+}
+
+func TestReconnectWaitTimeout(t *testing.T) {
+	td := &testDialer{}
+	d := newSupervisedDevice(t, td, 20*time.Millisecond)
+
+	td.setFail(true)
+	td.transport(0).Drop()
+	if err := d.SetDrawMode(1); !errors.Is(err, ErrReconnecting) {
+		t.Errorf("SetDrawMode() = %v, want %v", err, ErrReconnecting)
+	}
+}
+
+func TestDisconnectStopsReconnect(t *testing.T) {
+	td := &testDialer{}
+	d := newSupervisedDevice(t, td, 0)
+	events := make(chan Event, 16)
+	d.Notify(events)
+
+	td.setFail(true)
+	td.transport(0).Drop()
+	waitForState(t, events, StateReconnecting)
+	if err := d.Disconnect(); err != nil {
+		t.Fatalf("Disconnect() = %v", err)
+	}
+	if s := d.State(); s != StateDisconnected {
+		t.Errorf("State() = %s, want %s", s, StateDisconnected)
+	}
+	if err := d.SetDrawMode(1); !errors.Is(err, ErrNotConnected) {
+		t.Errorf("SetDrawMode() = %v, want %v", err, ErrNotConnected)
+	}
+}
+
+func TestReconnectUnsupervised(t *testing.T) {
+	td := &testDialer{}
+	d := NewDeviceWithDialer(td.dial)
+	if err := d.ConnectWithOptions(ConnectOptions{Supervise: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Disconnect(); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer d.Disconnect()
+
+	// Without a supervisor a failed write leaves the device as it was
+	td.transport(1).Drop()
+	for i := 0; i < 2; i++ {
+		if err := d.SetDrawMode(1); !errors.Is(err, ErrTransportClosed) {
+			t.Errorf("SetDrawMode() = %v, want %v", err, ErrTransportClosed)
+		}
+	}
+	if s := d.State(); s != StateConnected {
+		t.Errorf("State() = %s, want %s", s, StateConnected)
+	}
+}
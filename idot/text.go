@@ -0,0 +1,281 @@
+/*
+Copyright © 2024 Neil Johnson <nj.designs@protonmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idot
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"os"
+
+	"github.com/nj-designs/go-idot/idot/proto"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// TextMode is how text is animated on the display
+type TextMode uint8
+
+const (
+	TextStatic      TextMode = 0
+	TextScrollLeft  TextMode = 1
+	TextScrollRight TextMode = 2
+	TextScrollUp    TextMode = 3
+	TextScrollDown  TextMode = 4
+	TextBlink       TextMode = 5
+	TextBreathe     TextMode = 6
+	TextSnow        TextMode = 7
+	TextLaser       TextMode = 8
+)
+
+var textModeNames = []string{"static", "scroll-left", "scroll-right", "scroll-up", "scroll-down", "blink", "breathe", "snow", "laser"}
+
+func (m TextMode) String() string {
+	if int(m) < len(textModeNames) {
+		return textModeNames[m]
+	}
+	return fmt.Sprintf("TextMode(%d)", m)
+}
+
+// ParseTextMode returns the TextMode named s, e.g. "scroll-left"
+func ParseTextMode(s string) (TextMode, error) {
+	for i, name := range textModeNames {
+		if name == s {
+			return TextMode(i), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid text mode %q", s)
+}
+
+// TextColourMode selects how text is coloured
+type TextColourMode uint8
+
+const (
+	TextColourWhite    TextColourMode = 0
+	TextColourCustom   TextColourMode = 1 // use TextOptions.Colour
+	TextColourRainbow1 TextColourMode = 2
+	TextColourRainbow2 TextColourMode = 3
+	TextColourRainbow3 TextColourMode = 4
+	TextColourRainbow4 TextColourMode = 5
+)
+
+const (
+	MinTextSpeed = 1
+	MaxTextSpeed = 100
+)
+
+// Size of the bitmap each character is rendered to
+const (
+	glyphWidth  = 16
+	glyphHeight = 32
+)
+
+var ErrInvalidTextSpeed = errors.New("text speed must be between 1 and 100")
+
+var ErrTextTooLong = errors.New("text is too long")
+
+var ErrEmptyText = errors.New("text is empty")
+
+// TextOptions control how text is shown by SendText
+type TextOptions struct {
+	Mode       TextMode
+	Speed      int
+	ColourMode TextColourMode
+	Colour     Colour
+	// Background is the colour behind the text. Black leaves the background
+	// unlit.
+	Background Colour
+	// Face is the font used to render the text. nil means a built in 7x13
+	// font scaled to fill the display.
+	Face font.Face
+}
+
+// DefaultTextOptions scroll white text to the left
+var DefaultTextOptions = TextOptions{
+	Mode:       TextScrollLeft,
+	Speed:      95,
+	ColourMode: TextColourWhite,
+}
+
+// SendText renders text and shows it on the display
+func (d *Device) SendText(text string, opts TextOptions) error {
+	packet, err := textPacket(text, opts)
+	if err != nil {
+		return err
+	}
+	return d.Write(packet)
+}
+
+// LoadFontFace loads a TrueType or OpenType font for use as TextOptions.Face.
+// size is in pixels; around 24 suits a 32 pixel high display.
+func LoadFontFace(path string, size float64) (font.Face, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := opentype.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	return opentype.NewFace(f, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+}
+
+// textPacket builds the packet that shows text on the display
+func textPacket(text string, opts TextOptions) ([]byte, error) {
+	if len(text) == 0 {
+		return nil, ErrEmptyText
+	}
+	if opts.Speed < MinTextSpeed || opts.Speed > MaxTextSpeed {
+		return nil, ErrInvalidTextSpeed
+	}
+
+	packet, err := proto.Text{
+		Mode:         uint8(opts.Mode),
+		Speed:        uint8(opts.Speed),
+		ColourMode:   uint8(opts.ColourMode),
+		Colour:       proto.RGB(opts.Colour),
+		BackgroundOn: opts.Background != (Colour{}),
+		Background:   proto.RGB(opts.Background),
+		Glyphs:       textBitmaps(text, opts.Face),
+	}.MarshalBinary()
+	if errors.Is(err, proto.ErrPacketTooLong) {
+		return nil, ErrTextTooLong
+	}
+	return packet, err
+}
+
+// RenderText draws text as the display receives it, one glyph after another
+// in a single glyph high strip, for previewing. Rainbow colour modes are
+// approximated with a gradient.
+func RenderText(text string, opts TextOptions) (*image.RGBA, error) {
+	if len(text) == 0 {
+		return nil, ErrEmptyText
+	}
+
+	var glyphs []*image.Alpha
+	for _, r := range text {
+		glyphs = append(glyphs, renderGlyph(r, opts.Face))
+	}
+	img := image.NewRGBA(image.Rect(0, 0, len(glyphs)*glyphWidth, glyphHeight))
+	bg := color.RGBA{opts.Background.R, opts.Background.G, opts.Background.B, 255}
+	for i, g := range glyphs {
+		for y := 0; y < glyphHeight; y++ {
+			for x := 0; x < glyphWidth; x++ {
+				px := i*glyphWidth + x
+				if g.AlphaAt(x, y).A < 0x80 {
+					img.SetRGBA(px, y, bg)
+					continue
+				}
+				c := White
+				switch {
+				case opts.ColourMode == TextColourCustom:
+					c = opts.Colour
+				case opts.ColourMode >= TextColourRainbow1:
+					c = hue(px * 360 / img.Bounds().Dx())
+				}
+				img.SetRGBA(px, y, color.RGBA{c.R, c.G, c.B, 255})
+			}
+		}
+	}
+	return img, nil
+}
+
+// hue returns the fully saturated colour at h degrees round the colour wheel
+func hue(h int) Colour {
+	h %= 360
+	x := uint8(255 * (60 - abs(h%120-60)) / 60)
+	switch h / 60 {
+	case 0:
+		return Colour{255, x, 0}
+	case 1:
+		return Colour{x, 255, 0}
+	case 2:
+		return Colour{0, 255, x}
+	case 3:
+		return Colour{0, x, 255}
+	case 4:
+		return Colour{x, 0, 255}
+	default:
+		return Colour{255, 0, x}
+	}
+}
+
+// textBitmaps renders each character of text to a 1 bit per pixel bitmap
+func textBitmaps(text string, face font.Face) [][]byte {
+	var bitmaps [][]byte
+	for _, r := range text {
+		bitmaps = append(bitmaps, packGlyph(renderGlyph(r, face)))
+	}
+	return bitmaps
+}
+
+// renderGlyph draws r in to a glyphWidth x glyphHeight image
+func renderGlyph(r rune, face font.Face) *image.Alpha {
+	if face == nil {
+		// Draw at half size with the built in font, then double up
+		small := drawGlyph(r, basicfont.Face7x13, glyphWidth/2, glyphHeight/2)
+		img := image.NewAlpha(image.Rect(0, 0, glyphWidth, glyphHeight))
+		for y := 0; y < glyphHeight; y++ {
+			for x := 0; x < glyphWidth; x++ {
+				img.SetAlpha(x, y, small.AlphaAt(x/2, y/2))
+			}
+		}
+		return img
+	}
+	return drawGlyph(r, face, glyphWidth, glyphHeight)
+}
+
+// drawGlyph draws r centred in a width x height image
+func drawGlyph(r rune, face font.Face, width int, height int) *image.Alpha {
+	img := image.NewAlpha(image.Rect(0, 0, width, height))
+
+	metrics := face.Metrics()
+	advance, _ := face.GlyphAdvance(r)
+	x := (fixed.I(width) - advance) / 2
+	y := (fixed.I(height)-metrics.Ascent-metrics.Descent)/2 + metrics.Ascent
+
+	drawer := font.Drawer{Dst: img, Src: image.Opaque, Face: face, Dot: fixed.Point26_6{X: x, Y: y}}
+	drawer.DrawString(string(r))
+	return img
+}
+
+// packGlyph packs img to 1 bit per pixel, least significant bit first
+func packGlyph(img *image.Alpha) []byte {
+	bounds := img.Bounds()
+	packed := make([]byte, 0, bounds.Dx()*bounds.Dy()/8)
+	var b byte
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if img.AlphaAt(x, y).A >= 0x80 {
+				b |= 1 << (x % 8)
+			}
+			if x%8 == 7 {
+				packed = append(packed, b)
+				b = 0
+			}
+		}
+	}
+	return packed
+}
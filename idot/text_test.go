@@ -0,0 +1,135 @@
+/*
+Copyright © 2024 Neil Johnson <nj.designs@protonmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idot
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"strings"
+	"testing"
+)
+
+func TestTextPacketFixture(t *testing.T) {
+	opts := TextOptions{
+		Mode:       TextBlink,
+		Speed:      50,
+		ColourMode: TextColourCustom,
+		Colour:     Colour{R: 1, G: 2, B: 3},
+		Background: Colour{R: 4, G: 5, B: 6},
+	}
+	// A space renders to an empty glyph, so the whole packet is known
+	packet, err := textPacket(" ", opts)
+	if err != nil {
+		t.Fatalf("textPacket() = %v", err)
+	}
+
+	want := []byte{
+		// header: length, group, sub, 0, payload length, payload CRC32, trailer
+		98, 0, 3, 0, 0,
+		82, 0, 0, 0,
+		0x4d, 0x41, 0x5b, 0x74,
+		0, 0, 12,
+		// payload header: characters, 0, 1, mode, speed, colour mode,
+		// colour, background on, background
+		1, 0, 0, 1, 5, 50, 1,
+		1, 2, 3,
+		1, 4, 5, 6,
+		// glyph separator
+		2, 255, 255, 255,
+	}
+	want = append(want, make([]byte, glyphWidth*glyphHeight/8)...)
+	if !bytes.Equal(packet, want) {
+		t.Errorf("textPacket() =\n% x\nwant\n% x", packet, want)
+	}
+}
+
+func TestTextPacketLayout(t *testing.T) {
+	const text = "AB"
+	packet, err := textPacket(text, DefaultTextOptions)
+	if err != nil {
+		t.Fatalf("textPacket() = %v", err)
+	}
+
+	const glyphSize = glyphWidth * glyphHeight / 8
+	wantLen := 16 + 14 + len(text)*(4+glyphSize)
+	if len(packet) != wantLen {
+		t.Fatalf("packet is %d bytes, want %d", len(packet), wantLen)
+	}
+	if got := binary.LittleEndian.Uint16(packet); int(got) != wantLen {
+		t.Errorf("length field = %d, want %d", got, wantLen)
+	}
+	if got := packet[2:5]; !bytes.Equal(got, []byte{3, 0, 0}) {
+		t.Errorf("group = % x, want 03 00 00", got)
+	}
+	payload := packet[16:]
+	if got := binary.LittleEndian.Uint32(packet[5:]); int(got) != len(payload) {
+		t.Errorf("payload length field = %d, want %d", got, len(payload))
+	}
+	if got, want := binary.LittleEndian.Uint32(packet[9:]), crc32.ChecksumIEEE(payload); got != want {
+		t.Errorf("CRC field = %08x, want %08x", got, want)
+	}
+	if got := packet[13:16]; !bytes.Equal(got, []byte{0, 0, 12}) {
+		t.Errorf("header trailer = % x, want 00 00 0c", got)
+	}
+
+	wantHeader := []byte{2, 0, 0, 1, byte(TextScrollLeft), 95, byte(TextColourWhite), 0, 0, 0, 0, 0, 0, 0}
+	if got := payload[:14]; !bytes.Equal(got, wantHeader) {
+		t.Errorf("payload header = % x, want % x", got, wantHeader)
+	}
+	for i := range text {
+		glyph := payload[14+i*(4+glyphSize):][:4+glyphSize]
+		if !bytes.Equal(glyph[:4], []byte{2, 255, 255, 255}) {
+			t.Errorf("glyph %d separator = % x, want 02 ff ff ff", i, glyph[:4])
+		}
+		if bytes.Count(glyph[4:], []byte{0}) == glyphSize {
+			t.Errorf("glyph %d is empty", i)
+		}
+	}
+}
+
+func TestTextPacketErrors(t *testing.T) {
+	slow := DefaultTextOptions
+	slow.Speed = MinTextSpeed - 1
+	fast := DefaultTextOptions
+	fast.Speed = MaxTextSpeed + 1
+
+	tests := []struct {
+		name string
+		text string
+		opts TextOptions
+		want error
+	}{
+		{name: "empty", text: "", opts: DefaultTextOptions, want: ErrEmptyText},
+		{name: "speed too low", text: "A", opts: slow, want: ErrInvalidTextSpeed},
+		{name: "speed too high", text: "A", opts: fast, want: ErrInvalidTextSpeed},
+		{name: "too long", text: strings.Repeat("A", 1000), opts: DefaultTextOptions, want: ErrTextTooLong},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := textPacket(tc.text, tc.opts); !errors.Is(err, tc.want) {
+				t.Errorf("textPacket() = %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+/*
+Copyright © 2024 Neil Johnson <nj.designs@protonmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idot
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/nj-designs/go-idot/idot/proto"
+)
+
+const (
+	MaxCountdownMinutes = 99
+	MaxCountdownSeconds = 59
+)
+
+var ErrInvalidCountdown = errors.New("countdown must be between 0:00 and 99:59")
+
+var ErrInvalidCountdownAction = errors.New("invalid countdown action")
+
+var ErrInvalidChronographAction = errors.New("invalid stopwatch action")
+
+// CountdownAction controls the display's countdown timer
+type CountdownAction uint8
+
+const (
+	CountdownStop    CountdownAction = 0
+	CountdownStart   CountdownAction = 1
+	CountdownPause   CountdownAction = 2
+	CountdownRestart CountdownAction = 3
+)
+
+var countdownActionNames = []string{"stop", "start", "pause", "restart"}
+
+func (a CountdownAction) String() string {
+	if int(a) < len(countdownActionNames) {
+		return countdownActionNames[a]
+	}
+	return fmt.Sprintf("CountdownAction(%d)", a)
+}
+
+// Valid reports whether a is an action the display supports
+func (a CountdownAction) Valid() bool {
+	return int(a) < len(countdownActionNames)
+}
+
+// ParseCountdownAction returns the CountdownAction named s, e.g. "start"
+func ParseCountdownAction(s string) (CountdownAction, error) {
+	for i, name := range countdownActionNames {
+		if name == s {
+			return CountdownAction(i), nil
+		}
+	}
+	return 0, fmt.Errorf("%w %q", ErrInvalidCountdownAction, s)
+}
+
+// ChronographAction controls the display's stopwatch
+type ChronographAction uint8
+
+const (
+	ChronographReset    ChronographAction = 0
+	ChronographStart    ChronographAction = 1
+	ChronographPause    ChronographAction = 2
+	ChronographContinue ChronographAction = 3
+)
+
+var chronographActionNames = []string{"reset", "start", "pause", "continue"}
+
+func (a ChronographAction) String() string {
+	if int(a) < len(chronographActionNames) {
+		return chronographActionNames[a]
+	}
+	return fmt.Sprintf("ChronographAction(%d)", a)
+}
+
+// Valid reports whether a is an action the display supports
+func (a ChronographAction) Valid() bool {
+	return int(a) < len(chronographActionNames)
+}
+
+// ParseChronographAction returns the ChronographAction named s, e.g. "start"
+func ParseChronographAction(s string) (ChronographAction, error) {
+	for i, name := range chronographActionNames {
+		if name == s {
+			return ChronographAction(i), nil
+		}
+	}
+	return 0, fmt.Errorf("%w %q", ErrInvalidChronographAction, s)
+}
+
+// Countdown controls the display's countdown timer. minutes and seconds
+// set the time counted down from when starting or restarting.
+func (d *Device) Countdown(action CountdownAction, minutes int, seconds int) error {
+	if !action.Valid() {
+		return fmt.Errorf("%w %d", ErrInvalidCountdownAction, action)
+	}
+	if minutes < 0 || minutes > MaxCountdownMinutes || seconds < 0 || seconds > MaxCountdownSeconds {
+		return ErrInvalidCountdown
+	}
+	return d.send(proto.Countdown{Action: uint8(action), Minutes: uint8(minutes), Seconds: uint8(seconds)})
+}
+
+// Chronograph controls the display's stopwatch
+func (d *Device) Chronograph(action ChronographAction) error {
+	if !action.Valid() {
+		return fmt.Errorf("%w %d", ErrInvalidChronographAction, action)
+	}
+	return d.send(proto.Chronograph{Action: uint8(action)})
+}
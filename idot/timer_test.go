@@ -0,0 +1,91 @@
+/*
+Copyright © 2024 Neil Johnson <nj.designs@protonmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idot
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestCountdown(t *testing.T) {
+	rt := NewRecordingTransport()
+	d := newTestDevice(t, rt)
+	if err := d.Countdown(CountdownStart, 15, 30); err != nil {
+		t.Fatalf("Countdown() = %v", err)
+	}
+	if got, want := rt.Bytes(), []byte{7, 0, 8, 128, 1, 15, 30}; !bytes.Equal(got, want) {
+		t.Errorf("Countdown() sent % x, want % x", got, want)
+	}
+}
+
+func TestChronograph(t *testing.T) {
+	rt := NewRecordingTransport()
+	d := newTestDevice(t, rt)
+	if err := d.Chronograph(ChronographContinue); err != nil {
+		t.Fatalf("Chronograph() = %v", err)
+	}
+	if got, want := rt.Bytes(), []byte{5, 0, 9, 128, 3}; !bytes.Equal(got, want) {
+		t.Errorf("Chronograph() sent % x, want % x", got, want)
+	}
+}
+
+func TestTimerInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		send func(d *Device) error
+		want error
+	}{
+		{
+			name: "countdown action",
+			send: func(d *Device) error { return d.Countdown(CountdownAction(9), 1, 0) },
+			want: ErrInvalidCountdownAction,
+		},
+		{
+			name: "countdown minutes",
+			send: func(d *Device) error { return d.Countdown(CountdownStart, MaxCountdownMinutes+1, 0) },
+			want: ErrInvalidCountdown,
+		},
+		{
+			name: "countdown seconds",
+			send: func(d *Device) error { return d.Countdown(CountdownStart, 0, MaxCountdownSeconds+1) },
+			want: ErrInvalidCountdown,
+		},
+		{
+			name: "chronograph action",
+			send: func(d *Device) error { return d.Chronograph(ChronographAction(4)) },
+			want: ErrInvalidChronographAction,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rt := NewRecordingTransport()
+			d := newTestDevice(t, rt)
+			if err := tc.send(d); !errors.Is(err, tc.want) {
+				t.Errorf("err = %v, want %v", err, tc.want)
+			}
+			if got := rt.Bytes(); len(got) != 0 {
+				t.Errorf("sent % x", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+/*
+Copyright © 2024 Neil Johnson <nj.designs@protonmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idot
+
+import (
+	"context"
+	"time"
+)
+
+// DefaultTimeSyncInterval is how often KeepTimeSynced corrects the clock's
+// drift when no interval is given
+const DefaultTimeSyncInterval = 6 * time.Hour
+
+// timeSyncRetry is how soon a failed sync is retried
+const timeSyncRetry = time.Minute
+
+// TimeSyncOptions control KeepTimeSynced
+type TimeSyncOptions struct {
+	// Location is the time zone the display shows. nil means time.Local.
+	Location *time.Location
+	// Interval between syncs. 0 means DefaultTimeSyncInterval.
+	Interval time.Duration
+	// OnSync, if set, is called after each attempt with the time sent
+	OnSync func(t time.Time, err error)
+}
+
+// SyncTime sets the display's clock to t, in t's location
+func (d *Device) SyncTime(t time.Time) error {
+	return d.SetTime(t.Year(), int(t.Month()), t.Day(), int(t.Weekday())+1, t.Hour(), t.Minute(), t.Second())
+}
+
+// KeepTimeSynced sets the display's clock now, then again every
+// opts.Interval and just after each daylight saving change in
+// opts.Location, until ctx is done. Failed syncs are retried after a minute.
+func (d *Device) KeepTimeSynced(ctx context.Context, opts TimeSyncOptions) error {
+	loc := opts.Location
+	if loc == nil {
+		loc = time.Local
+	}
+	interval := opts.Interval
+	if interval <= 0 {
+		interval = DefaultTimeSyncInterval
+	}
+
+	for {
+		now := time.Now().In(loc)
+		err := d.WithContext(ctx).SyncTime(now)
+		if opts.OnSync != nil {
+			opts.OnSync(now, err)
+		}
+
+		wait := interval
+		if err != nil {
+			wait = min(wait, timeSyncRetry)
+		}
+		if change, ok := nextZoneChange(now, wait); ok {
+			// Sync a moment after the change so the new offset is picked up
+			wait = change.Sub(now) + time.Second
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// nextZoneChange returns when the UTC offset of t's location next changes,
+// if that is within limit
+func nextZoneChange(t time.Time, limit time.Duration) (time.Time, bool) {
+	_, offset := t.Zone()
+	changed := func(at time.Time) bool {
+		_, o := at.Zone()
+		return o != offset
+	}
+
+	// Offsets change at most a couple of times a year, so stepping a day
+	// at a time finds the first change
+	lo := t
+	var hi time.Time
+	for step := time.Duration(0); step < limit; {
+		step = min(step+24*time.Hour, limit)
+		if at := t.Add(step); changed(at) {
+			hi = at
+			break
+		}
+		lo = t.Add(step)
+	}
+	if hi.IsZero() {
+		return time.Time{}, false
+	}
+
+	for hi.Sub(lo) > time.Second {
+		mid := lo.Add(hi.Sub(lo) / 2)
+		if changed(mid) {
+			hi = mid
+		} else {
+			lo = mid
+		}
+	}
+	return hi.Truncate(time.Second), true
+}
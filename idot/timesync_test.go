@@ -0,0 +1,98 @@
+/*
+Copyright © 2024 Neil Johnson <nj.designs@protonmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idot
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func TestNextZoneChange(t *testing.T) {
+	london, err := time.LoadLocation("Europe/London")
+	if err != nil {
+		t.Fatal(err)
+	}
+	utc := func(month time.Month, day int, hour int, min int, sec int, nsec int) time.Time {
+		return time.Date(2024, month, day, hour, min, sec, nsec, time.UTC)
+	}
+
+	tests := []struct {
+		name   string
+		from   time.Time
+		limit  time.Duration
+		want   time.Time
+		wantOK bool
+	}{
+		{
+			name:   "just before spring forward",
+			from:   utc(time.March, 31, 0, 59, 59, 0).In(london),
+			limit:  time.Hour,
+			want:   utc(time.March, 31, 1, 0, 0, 0),
+			wantOK: true,
+		},
+		{
+			name:   "part way through a second",
+			from:   utc(time.March, 31, 0, 59, 59, 500000000).In(london),
+			limit:  time.Hour,
+			want:   utc(time.March, 31, 1, 0, 0, 0),
+			wantOK: true,
+		},
+		{
+			name:   "days before spring forward",
+			from:   utc(time.March, 20, 12, 0, 0, 0).In(london),
+			limit:  30 * 24 * time.Hour,
+			want:   utc(time.March, 31, 1, 0, 0, 0),
+			wantOK: true,
+		},
+		{
+			name:   "fall back",
+			from:   utc(time.October, 20, 0, 0, 0, 0).In(london),
+			limit:  8 * 24 * time.Hour,
+			want:   utc(time.October, 27, 1, 0, 0, 0),
+			wantOK: true,
+		},
+		{
+			name:  "no change in window",
+			from:  utc(time.June, 1, 0, 0, 0, 0).In(london),
+			limit: 7 * 24 * time.Hour,
+		},
+		{
+			name:  "change after limit",
+			from:  utc(time.March, 30, 12, 0, 0, 0).In(london),
+			limit: 12 * time.Hour,
+		},
+		{
+			name:  "utc",
+			from:  utc(time.January, 1, 0, 0, 0, 0),
+			limit: 400 * 24 * time.Hour,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := nextZoneChange(tc.from, tc.limit)
+			if ok != tc.wantOK || !got.Equal(tc.want) {
+				t.Errorf("nextZoneChange() = %v, %v, want %v, %v", got, ok, tc.want, tc.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,188 @@
+/*
+Copyright © 2024 Neil Johnson <nj.designs@protonmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idot
+
+import (
+	"errors"
+	"sync"
+)
+
+// Transport is the link a Device uses to exchange packets with a display.
+// The Bluetooth LE backend is created by Device.Connect, other backends can
+// be supplied with NewDeviceWithTransport.
+type Transport interface {
+	// Write sends a single packet to the display. Packets are at most one
+	// write-sized slice of a command, as chunked by Device.Write.
+	Write(packet []byte) error
+	// MTU returns the largest packet Write accepts, or 0 if unknown
+	MTU() int
+	// Subscribe registers fn to be called for every notification received
+	// from the display.
+	Subscribe(fn func(buf []byte)) error
+	// Close releases the link.
+	Close() error
+}
+
+var ErrTransportClosed = errors.New("transport is closed")
+
+var ErrPacketTooLarge = errors.New("packet exceeds transport MTU")
+
+// RecordingTransport is an in-memory Transport that records every packet
+// written to it. It is intended for tests that need to check the exact
+// bytes a command emits without a real display. The zero value is ready
+// to use.
+type RecordingTransport struct {
+	// OnWrite, if set, is called after each packet has been recorded. It
+	// can be used to script replies from the display with Notify.
+	OnWrite func(packet []byte)
+	// MaxPacketSize, if non zero, is reported as the MTU and writes of
+	// larger packets fail with ErrPacketTooLarge
+	MaxPacketSize int
+
+	mu           sync.Mutex
+	packets      [][]byte
+	notify       func(buf []byte)
+	closed       bool
+	disconnected chan struct{} // created on first use
+}
+
+// NewRecordingTransport returns an empty RecordingTransport
+func NewRecordingTransport() *RecordingTransport {
+	return &RecordingTransport{}
+}
+
+// Write records a copy of packet
+func (t *RecordingTransport) Write(packet []byte) error {
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return ErrTransportClosed
+	}
+	if t.MaxPacketSize > 0 && len(packet) > t.MaxPacketSize {
+		t.mu.Unlock()
+		return ErrPacketTooLarge
+	}
+	t.packets = append(t.packets, append([]byte(nil), packet...))
+	t.mu.Unlock()
+
+	if t.OnWrite != nil {
+		t.OnWrite(packet)
+	}
+	return nil
+}
+
+// MTU returns MaxPacketSize
+func (t *RecordingTransport) MTU() int {
+	return t.MaxPacketSize
+}
+
+// Subscribe registers fn to be called by Notify
+func (t *RecordingTransport) Subscribe(fn func(buf []byte)) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.notify = fn
+	return nil
+}
+
+// Close marks the transport as closed. Further writes fail.
+func (t *RecordingTransport) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.closed = true
+	return nil
+}
+
+// Disconnected returns a channel that is closed by Drop
+func (t *RecordingTransport) Disconnected() <-chan struct{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.disconnectedLocked()
+}
+
+// disconnectedLocked returns the channel Drop closes. t.mu must be held.
+func (t *RecordingTransport) disconnectedLocked() chan struct{} {
+	if t.disconnected == nil {
+		t.disconnected = make(chan struct{})
+	}
+	return t.disconnected
+}
+
+// Drop simulates the link to the display dropping. Further writes fail.
+func (t *RecordingTransport) Drop() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.closed = true
+	disconnected := t.disconnectedLocked()
+	select {
+	case <-disconnected:
+	default:
+		close(disconnected)
+	}
+}
+
+// Notify delivers buf to the subscribed callback, as if it had been sent
+// by the display
+func (t *RecordingTransport) Notify(buf []byte) {
+	t.mu.Lock()
+	fn := t.notify
+	t.mu.Unlock()
+
+	if fn != nil {
+		fn(buf)
+	}
+}
+
+// Packets returns a copy of all packets written so far, in order
+func (t *RecordingTransport) Packets() [][]byte {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	packets := make([][]byte, len(t.packets))
+	for i, p := range t.packets {
+		packets[i] = append([]byte(nil), p...)
+	}
+	return packets
+}
+
+// Bytes returns all packets written so far concatenated together
+func (t *RecordingTransport) Bytes() []byte {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var buf []byte
+	for _, p := range t.packets {
+		buf = append(buf, p...)
+	}
+	return buf
+}
+
+// Reset discards all recorded packets
+func (t *RecordingTransport) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.packets = nil
+}
@@ -0,0 +1,162 @@
+/*
+Copyright © 2024 Neil Johnson <nj.designs@protonmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idot
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/nj-designs/go-idot/idot/proto"
+)
+
+// newTestDevice returns a Device connected to a RecordingTransport
+func newTestDevice(t *testing.T, rt *RecordingTransport) *Device {
+	t.Helper()
+	d := NewDeviceWithTransport(rt)
+	if err := d.Connect(); err != nil {
+		t.Fatalf("Connect() = %v", err)
+	}
+	t.Cleanup(func() { d.Disconnect() })
+	return d
+}
+
+// ackEveryWrite makes rt reply to every packet written to it with status
+func ackEveryWrite(rt *RecordingTransport, group uint8, status uint8) {
+	reply, _ := proto.Reply{Group: group, Status: status}.MarshalBinary()
+	rt.OnWrite = func(packet []byte) {
+		rt.Notify(reply)
+	}
+}
+
+func TestCommandBytes(t *testing.T) {
+	tests := []struct {
+		name string
+		send func(d *Device) error
+		want []byte
+	}{
+		{
+			name: "SetClockMode",
+			send: func(d *Device) error {
+				return d.SetClockMode(ClockAnimatedHourGlass, true, true, Colour{R: 1, G: 2, B: 3})
+			},
+			want: []byte{8, 0, 6, 1, 4 | 128 | 64, 1, 2, 3},
+		},
+		{
+			name: "SetClockMode no date 12h",
+			send: func(d *Device) error {
+				return d.SetClockMode(ClockRacing, false, false, Colour{R: 255, G: 0, B: 0})
+			},
+			want: []byte{8, 0, 6, 1, 2, 255, 0, 0},
+		},
+		{
+			name: "SetTime",
+			send: func(d *Device) error {
+				return d.SetTime(2024, 3, 15, 6, 13, 45, 30)
+			},
+			want: []byte{11, 0, 1, 128, 24, 3, 15, 6, 13, 45, 30},
+		},
+		{
+			name: "SetDrawMode",
+			send: func(d *Device) error {
+				return d.SetDrawMode(1)
+			},
+			want: []byte{5, 0, 4, 1, 1},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rt := NewRecordingTransport()
+			d := newTestDevice(t, rt)
+			if err := tc.send(d); err != nil {
+				t.Fatalf("err = %v", err)
+			}
+			if got := rt.Bytes(); !bytes.Equal(got, tc.want) {
+				t.Errorf("sent % x, want % x", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSendImageBytes(t *testing.T) {
+	img, err := os.ReadFile("../testdata/demo_32.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rt := NewRecordingTransport()
+	ackEveryWrite(rt, GroupImage, proto.StatusNext)
+	d := newTestDevice(t, rt)
+	if err := d.SendImage(img); err != nil {
+		t.Fatalf("SendImage() = %v", err)
+	}
+
+	// Length is the image size plus the number of chunks
+	want := append([]byte{0xaf, 0x01, 0, 0, 0, 0xae, 0x01, 0, 0}, img...)
+	if got := rt.Bytes(); !bytes.Equal(got, want) {
+		t.Errorf("sent % x, want % x", got, want)
+	}
+}
+
+func TestSendImageChunks(t *testing.T) {
+	img := noisePNG(t)
+
+	rt := NewRecordingTransport()
+	ackEveryWrite(rt, GroupImage, proto.StatusNext)
+	d := newTestDevice(t, rt)
+	if err := d.SendImage(img); err != nil {
+		t.Fatalf("SendImage() = %v", err)
+	}
+
+	n := len(img)
+	length := []byte{uint8(n + 2), uint8((n + 2) >> 8)}
+	total := []byte{uint8(n), uint8(n >> 8), 0, 0}
+	var want []byte
+	want = append(want, length...)
+	want = append(want, 0, 0, 0)
+	want = append(want, total...)
+	want = append(want, img[:proto.ChunkSize]...)
+	want = append(want, length...)
+	want = append(want, 0, 0, 2)
+	want = append(want, total...)
+	want = append(want, img[proto.ChunkSize:]...)
+	if got := rt.Bytes(); !bytes.Equal(got, want) {
+		t.Errorf("sent %d bytes, want %d\ngot  % x\nwant % x", len(got), len(want), got[:32], want[:32])
+	}
+}
+
+func TestRecordingTransportZeroValue(t *testing.T) {
+	rt := &RecordingTransport{MaxPacketSize: 20}
+	disconnected := rt.Disconnected()
+	rt.Drop()
+	rt.Drop()
+	select {
+	case <-disconnected:
+	default:
+		t.Error("Disconnected() not closed by Drop")
+	}
+	if err := rt.Write([]byte{1}); !errors.Is(err, ErrTransportClosed) {
+		t.Errorf("Write() after Drop = %v, want %v", err, ErrTransportClosed)
+	}
+}